@@ -1,21 +1,101 @@
 package config
 
 import (
+	"context"
+	stdLog "log"
+	"strings"
+	"time"
+
 	"github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
 	"github.com/Aboagye-Dacosta/shopBackend/cmd/router"
 	"github.com/Aboagye-Dacosta/shopBackend/cmd/service"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/catalog"
 	database "github.com/Aboagye-Dacosta/shopBackend/internal/database/db"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/keyring"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/mailer"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/oidc"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/ratelimit"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/revocation"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
-func Setup() *mux.Router {
+// Setup wires the application and starts its background jobs.
+// The returned function stops the background jobs.
+func Setup() (*mux.Router, func()) {
 	log := logger.Init()
+
+	keys, err := keyring.Load()
+	if err != nil {
+		stdLog.Fatal("Failed to load JWT signing keys: ", err)
+	}
+	keyring.SetDefault(keys)
+	if strings.HasPrefix(keys.Active().ID, "ephemeral-") {
+		stdLog.Printf("⚠️ No JWT keys configured, signing tokens with ephemeral key %s", keys.Active().ID)
+	}
+
 	db := database.ConnectDB()
 	md := models.NewModel(db)
-	sr := service.NewService(md)
+
+	checkPermissionCatalog(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	revoked := newRevocationStore(db)
+	revocation.StartPurger(ctx, revoked, env.GetDurationEnv("REVOCATION_PURGE_INTERVAL", 10*time.Minute), log)
+
+	mail, err := mailer.New()
+	if err != nil {
+		stdLog.Fatal("Failed to configure mailer: ", err)
+	}
+
+	providers, err := oidc.LoadProviders()
+	if err != nil {
+		stdLog.Fatal("Failed to configure OIDC providers: ", err)
+	}
+
+	sr := service.NewService(md, revoked, mail, providers)
+	sr.PrivacyService.StartJobs(ctx, env.GetDurationEnv("PRIVACY_JOB_INTERVAL", 15*time.Minute), log)
+	sr.UserService.StartRoleGrantSweeper(ctx, env.GetDurationEnv("ROLE_GRANT_SWEEP_INTERVAL", time.Minute), log)
+	sr.LockoutService.StartAttemptSweeper(ctx, env.GetDurationEnv("LOGIN_ATTEMPT_SWEEP_INTERVAL", 5*time.Minute))
+	sr.PermissionService.StartCachePurger(ctx, env.GetDurationEnv("PERMISSION_CACHE_PURGE_INTERVAL", 5*time.Minute))
+	sr.AuthService.StartLastSeenPruner(ctx, env.GetDurationEnv("SESSION_TOUCH_PRUNE_INTERVAL", 10*time.Minute))
 	ct := controller.NewController(sr)
 
-	return router.InitRouter(ct, log)
+	limits := ratelimit.NewMemoryStore()
+	ratelimit.StartPurger(ctx, limits, env.GetDurationEnv("RATE_LIMIT_PURGE_INTERVAL", 5*time.Minute), log)
+
+	return router.InitRouter(ct, sr, limits, log), cancel
+}
+
+// checkPermissionCatalog reports where the permissions table disagrees with the catalog
+// in code. PERMISSION_CATALOG_SYNC=true writes the missing and outdated ones instead.
+func checkPermissionCatalog(db *gorm.DB) {
+	check := catalog.Diff
+	if env.GetBoolEnv("PERMISSION_CATALOG_SYNC", false) {
+		check = catalog.Sync
+	}
+
+	drift, err := check(db)
+	if err != nil {
+		stdLog.Printf("⚠️ Failed to check the permission catalog: %v", err)
+		return
+	}
+	if drift.Empty() {
+		return
+	}
+
+	stdLog.Printf("⚠️ Permission catalog drift: missing in database %v, unknown to the code %v, outdated %v", drift.Missing, drift.Unknown, drift.Outdated)
+}
+
+// newRevocationStore selects the revocation backend from REVOCATION_STORE ("postgres" or "memory").
+func newRevocationStore(db *gorm.DB) revocation.Store {
+	if env.GetStringEnv("REVOCATION_STORE", "postgres") == "memory" {
+		return revocation.NewMemoryStore()
+	}
+
+	return revocation.NewPostgresStore(db)
 }
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// createAPIKey godoc
+// @Summary      Create API key
+// @Description  Create a service account API key limited to the given permissions. The key never grants more than its creator currently holds and stops working if the creator is suspended or deleted. The key is only returned in this response.
+// @Tags         API Keys
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.CreateAPIKeyRequest  true  "API key"
+// @Success      201  {object} models.Response{data=models.APIKeyCreatedResponse}
+// @Failure      400  {object} models.ErrResponse
+// @Failure      403  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/api-keys [post]
+func (c *Controller) HttpCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request models.CreateAPIKeyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.API_KEY, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+	permissions := r.Context().Value(constants.PERMISSIONS_KEY).([]string)
+
+	created, err := c.apiKeyService.Create(r.Context(), userID, permissions, &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.API_KEY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.API_KEY, http.StatusCreated, created)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getAPIKeys godoc
+// @Summary      Get API keys
+// @Description  List API keys without their secret
+// @Tags         API Keys
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=[]models.APIKey}
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/api-keys [get]
+func (c *Controller) HttpGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := c.apiKeyService.GetAll(r.Context())
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.API_KEY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.API_KEY, http.StatusOK, keys)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// revokeAPIKey godoc
+// @Summary      Revoke API key
+// @Description  Disable an API key immediately
+// @Tags         API Keys
+// @Security     BearerAuth
+// @Param        id   path      string  true  "API key ID"
+// @Produce      json
+// @Success      200  {object} models.Response
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/api-keys/{id} [delete]
+func (c *Controller) HttpRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := c.apiKeyService.Revoke(r.Context(), id); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.API_KEY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.API_KEY, http.StatusNoContent, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// checkPermission godoc
+// @Summary      Explain a permission
+// @Description  Explain whether a user holds a permission: the roles granting it, directly, through inheritance, a wildcard or full_access, or the rule denying it. Checking another user requires roles:manage.
+// @Tags         Roles and Permissions
+// @Security     BearerAuth
+// @Produce      json
+// @Param        permission  query     string  true   "Permission name"
+// @Param        user        query     string  false  "User ID, defaults to the caller"
+// @Success      200  {object} models.Response{data=models.PermissionCheck}
+// @Failure      400  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/authz/check [get]
+func (c *Controller) HttpCheckPermission(w http.ResponseWriter, r *http.Request) {
+	permission := r.URL.Query().Get("permission")
+	if permission == "" {
+		response := &models.Response{
+			Success: false,
+			Message: "permission is required",
+			Code:    http.StatusBadRequest,
+		}
+
+		if err := utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	callerID, _ := r.Context().Value(constants.USER_ID_KEY).(string)
+	permissions, _ := r.Context().Value(constants.PERMISSIONS_KEY).([]string)
+
+	userID := r.URL.Query().Get("user")
+	if userID == "" {
+		userID = callerID
+	}
+
+	if userID == "" || (userID != callerID && !utils.HasPermission(permissions, constants.ManageRoles)) {
+		resp := utils.GenErrorResponse(entities.PERMISSIONS, http.StatusForbidden, errors.New("checking another user requires roles:manage"))
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	check, err := c.permissionService.Explain(r.Context(), userID, permission)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PERMISSIONS, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PERMISSIONS, http.StatusOK, check)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getMyPermissions godoc
+// @Summary      Get my permissions
+// @Description  Get the permissions of the caller as granted, wildcards included, and as the list of known permissions they cover, for clients deciding what to show.
+// @Tags         Profile
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=models.MyPermissions}
+// @Failure      401  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/permissions [get]
+func (c *Controller) HttpGetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, _ := r.Context().Value(constants.PERMISSIONS_KEY).([]string)
+
+	mine, err := c.permissionService.Effective(r.Context(), permissions)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PERMISSIONS, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PERMISSIONS, http.StatusOK, mine)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -5,15 +5,43 @@ import (
 )
 
 type Controller struct {
-	userService       *service.UserService
-	permissionService *service.PermissionService
-	rolesService      *service.RoleService
+	userService          *service.UserService
+	permissionService    *service.PermissionService
+	rolesService         *service.RoleService
+	authService          *service.AuthService
+	verificationService  *service.VerificationService
+	passwordService      *service.PasswordService
+	mfaService           *service.MFAService
+	lockoutService       *service.LockoutService
+	oidcService          *service.OIDCService
+	apiKeyService        *service.APIKeyService
+	sessionService       *service.SessionService
+	impersonationService *service.ImpersonationService
+	suspensionService    *service.SuspensionService
+	profileService       *service.ProfileService
+	privacyService       *service.PrivacyService
+	orderService         *service.OrderService
+	paymentService       *service.PaymentService
 }
 
 func NewController(s *service.Service) *Controller {
 	return &Controller{
-		userService:       s.UserService,
-		permissionService: s.PermissionService,
-		rolesService:      s.RoleService,
+		userService:          s.UserService,
+		permissionService:    s.PermissionService,
+		rolesService:         s.RoleService,
+		authService:          s.AuthService,
+		verificationService:  s.VerificationService,
+		passwordService:      s.PasswordService,
+		mfaService:           s.MFAService,
+		lockoutService:       s.LockoutService,
+		oidcService:          s.OIDCService,
+		apiKeyService:        s.APIKeyService,
+		sessionService:       s.SessionService,
+		impersonationService: s.ImpersonationService,
+		suspensionService:    s.SuspensionService,
+		profileService:       s.ProfileService,
+		privacyService:       s.PrivacyService,
+		orderService:         s.OrderService,
+		paymentService:       s.PaymentService,
 	}
 }
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// impersonateUser godoc
+// @Summary      Impersonate user
+// @Description  Sign in as another user to reproduce a problem. The token is short lived, cannot be refreshed and only carries permissions the caller also holds. Every impersonation is recorded.
+// @Tags         Users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                     true  "User ID"
+// @Param        request  body      models.ImpersonateRequest  true  "Reason for the impersonation"
+// @Success      200  {object} models.Response{data=models.ImpersonationResponse}
+// @Failure      400  {object} models.ErrResponse
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id}/impersonate [post]
+func (c *Controller) HttpImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	var request models.ImpersonateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	claims := r.Context().Value(constants.CLAIMS_KEY).(*utils.Claims)
+
+	impersonation, err := c.impersonationService.Start(r.Context(), claims, mux.Vars(r)["id"], request.Reason)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.IMPERSONATION_STARTED, impersonation)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
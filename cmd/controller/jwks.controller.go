@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/keyring"
+)
+
+// jwks godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys for verifying access tokens, selected by the kid header of a token
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object} keyring.JWKSet
+// @Router       /.well-known/jwks.json [get]
+func (c *Controller) HttpJWKS(w http.ResponseWriter, r *http.Request) {
+	keys := keyring.Default()
+	if keys == nil {
+		http.Error(w, "signing keys not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Served as a bare JWK set, verifiers do not understand the Response envelope
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(keys.JWKS()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -2,10 +2,11 @@ package controller
 
 import (
 	"encoding/json"
-	"fmt"
-
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/service"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
@@ -21,7 +22,10 @@ import (
 // @Produce      json
 // @Param        request body models.LoginRequest true "Login data"
 // @Success      200  {object} models.AuthResponse
+// @Success      202  {object} models.MFAChallengeResponse "Second factor required"
 // @Failure      400  {object} models.Response
+// @Failure      401  {object} models.Response "Invalid email or password"
+// @Failure      429  {object} models.Response "Too many failed attempts, see Retry-After"
 // @Failure      500  {object} models.Response
 // @Router       /api/v1/auth/login [post]
 func (c *Controller) HttpLoginUser(w http.ResponseWriter, r *http.Request) {
@@ -51,10 +55,15 @@ func (c *Controller) HttpLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	existingUser, err := c.userService.GetByEmail(r.Context(), user.Email)
+	existingUser, err := c.lockoutService.Login(r.Context(), user.Email, user.Password)
 
 	if err != nil {
 		if appErr, ok := err.(*appError.AppError); ok {
+			var throttled *service.LoginThrottledError
+			if errors.As(appErr.Err, &throttled) {
+				w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			}
+
 			response := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
 			if sendErr := utils.SendResponse(w, response); sendErr != nil {
 				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
@@ -71,8 +80,8 @@ func (c *Controller) HttpLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if existingUser == nil {
-		resp := utils.GenErrorResponse(entities.USER, http.StatusNotFound, fmt.Errorf("User does not exist"))
+	if existingUser.ActivatedAt == nil && c.verificationService.Required() {
+		resp := utils.GenAuthResponse(codes.EMAIL_NOT_VERIFIED, http.StatusForbidden)
 		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
 			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
 		}
@@ -80,27 +89,50 @@ func (c *Controller) HttpLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := utils.VerifyWithHashed(user.Password, existingUser.Password); err != nil {
-		resp := utils.GenAuthResponse(codes.INVALID_PASSWORD, http.StatusBadRequest)
-		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
-			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+	challenge, err := c.mfaService.Challenge(r.Context(), existingUser)
+
+	if err != nil {
+		if appErr, ok := err.(*appError.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Second factor required, tokens are issued by /auth/mfa/verify
+	if challenge != nil {
+		resp := utils.GenSuccessResponse(entities.USER, codes.MFA_REQUIRED, challenge)
+		if err := utils.SendResponse(w, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 
 		return
 	}
 
-	token, err := utils.GenerateJWT(existingUser.ID, existingUser.Roles)
+	authResponse, err := c.authService.IssueTokens(r.Context(), existingUser)
 
 	if err != nil {
+		if appErr, ok := err.(*appError.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Success response
-	resp := utils.GenSuccessResponse(entities.USER, codes.LOGIN_SUCCESS, models.AuthResponse{
-		Token: token,
-		User:  *existingUser,
-	})
+	resp := utils.GenSuccessResponse(entities.USER, codes.LOGIN_SUCCESS, authResponse)
 
 	if err := utils.SendResponse(w, resp); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
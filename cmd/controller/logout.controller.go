@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// logoutUser godoc
+// @Summary      Logout
+// @Description  Revoke the current access token and its refresh token
+// @Tags         Auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/logout [post]
+func (c *Controller) HttpLogout(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(constants.CLAIMS_KEY).(*utils.Claims)
+
+	if err := c.authService.Logout(r.Context(), claims); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.LOGOUT_SUCCESS, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// logoutAll godoc
+// @Summary      Logout everywhere
+// @Description  Revoke every access token and refresh token of the current user
+// @Tags         Auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      403  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/logout-all [post]
+func (c *Controller) HttpLogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	if err := c.authService.LogoutAll(r.Context(), userID); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.LOGOUT_SUCCESS, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
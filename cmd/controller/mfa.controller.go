@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/service"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// verifyMFA godoc
+// @Summary      Verify second factor
+// @Description  Complete a login that returned mfa_required with a TOTP code or a recovery code
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.MFAVerifyRequest true "Pending MFA token and code"
+// @Success      200  {object} models.AuthResponse
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      429  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/mfa/verify [post]
+func (c *Controller) HttpVerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var request models.MFAVerifyRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	authResponse, err := c.mfaService.Verify(r.Context(), &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			var throttled *service.LoginThrottledError
+			if errors.As(appErr.Err, &throttled) {
+				w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			}
+
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.LOGIN_SUCCESS, authResponse)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// enrollMFA godoc
+// @Summary      Start TOTP enrollment
+// @Description  Generate a TOTP secret and provisioning URI for an authenticator app. Accepts an access token or a pending MFA token.
+// @Tags         Auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.MFAEnrollResponse
+// @Failure      401  {object} models.Response
+// @Failure      403  {object} models.Response
+// @Failure      409  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/mfa/enroll [post]
+func (c *Controller) HttpEnrollMFA(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	enrollment, err := c.mfaService.Enroll(r.Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.MFA_ENROLLMENT_STARTED, enrollment)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// confirmMFA godoc
+// @Summary      Confirm TOTP enrollment
+// @Description  Enable two-factor authentication with a code from the authenticator app. Returns recovery codes, shown only once.
+// @Tags         Auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.MFACodeRequest true "TOTP code"
+// @Success      200  {object} models.RecoveryCodesResponse
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/mfa/enroll/confirm [post]
+func (c *Controller) HttpConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	var request models.MFACodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	recoveryCodes, err := c.mfaService.Confirm(r.Context(), userID, request.Code)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.MFA_ENABLED, recoveryCodes)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// disableMFA godoc
+// @Summary      Disable two-factor authentication
+// @Description  Turn off TOTP with a current code. Not allowed when a role of the user requires it. Wrong codes count toward the account lockout.
+// @Tags         Auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.MFACodeRequest true "TOTP code"
+// @Success      200  {object} models.Response
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      403  {object} models.Response
+// @Failure      429  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/mfa/disable [post]
+func (c *Controller) HttpDisableMFA(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	var request models.MFACodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := c.mfaService.Disable(r.Context(), userID, request.Code); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			var throttled *service.LoginThrottledError
+			if errors.As(appErr.Err, &throttled) {
+				w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			}
+
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.MFA_DISABLED, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// regenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replace every recovery code of the user. Requires a current TOTP code. Wrong codes count toward the account lockout.
+// @Tags         Auth
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.MFACodeRequest true "TOTP code"
+// @Success      200  {object} models.RecoveryCodesResponse
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      403  {object} models.Response
+// @Failure      429  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/mfa/recovery-codes [post]
+func (c *Controller) HttpRegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	var request models.MFACodeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	recoveryCodes, err := c.mfaService.RegenerateRecoveryCodes(r.Context(), userID, request.Code)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			var throttled *service.LoginThrottledError
+			if errors.As(appErr.Err, &throttled) {
+				w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			}
+
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.MFA_ENABLED, recoveryCodes)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
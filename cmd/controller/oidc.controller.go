@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// oidcStateCookie holds the state binding of a social login in the browser that started it.
+const oidcStateCookie = "oidc_state"
+
+// setOIDCStateCookie stores binding for the callback of the provider only. maxAge below
+// zero clears the cookie.
+func setOIDCStateCookie(w http.ResponseWriter, path, binding string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    binding,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		// Lax still sends the cookie on the top level redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// oidcStart godoc
+// @Summary      Start social login
+// @Description  Redirect to the sign in page of an OpenID Connect provider. Sets the oidc_state cookie the callback requires.
+// @Tags         Auth
+// @Param        provider path string true "Provider name from OIDC_PROVIDERS"
+// @Success      302
+// @Failure      404  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/oidc/{provider}/start [get]
+func (c *Controller) HttpOIDCStart(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+
+	authURL, binding, err := c.oidcService.Start(r.Context(), provider)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	ttl := env.GetDurationEnv("OIDC_STATE_TTL", 10*time.Minute)
+	setOIDCStateCookie(w, strings.TrimSuffix(r.URL.Path, "/start"), binding, int(ttl/time.Second))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// oidcCallback godoc
+// @Summary      Complete social login
+// @Description  Redirect target of the provider. Requires the oidc_state cookie set by the start request in the same browser. Links the identity to an account by verified email and signs the user in.
+// @Tags         Auth
+// @Produce      json
+// @Param        provider path  string true "Provider name from OIDC_PROVIDERS"
+// @Param        state    query string true "State from the start request"
+// @Param        code     query string true "Authorization code"
+// @Success      200  {object} models.AuthResponse
+// @Success      202  {object} models.MFAChallengeResponse "Second factor required"
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      403  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/oidc/{provider}/callback [get]
+func (c *Controller) HttpOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	query := r.URL.Query()
+
+	// The binding is good for one attempt, whatever its outcome
+	binding := ""
+	if cookie, err := r.Cookie(oidcStateCookie); err == nil {
+		binding = cookie.Value
+	}
+	setOIDCStateCookie(w, strings.TrimSuffix(r.URL.Path, "/callback"), "", -1)
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, codes.OIDC_LOGIN_FAILED, fmt.Errorf("provider returned %s: %s", providerErr, query.Get("error_description")))
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if query.Get("state") == "" || query.Get("code") == "" {
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, codes.INVALID_OAUTH_STATE, fmt.Errorf("state and code are required"))
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	user, err := c.oidcService.Callback(r.Context(), provider, query.Get("state"), binding, query.Get("code"))
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	challenge, err := c.mfaService.Challenge(r.Context(), user)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Social login does not skip the second factor of privileged accounts
+	if challenge != nil {
+		resp := utils.GenSuccessResponse(entities.USER, codes.MFA_REQUIRED, challenge)
+		if err := utils.SendResponse(w, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	authResponse, err := c.authService.IssueTokens(r.Context(), user)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.LOGIN_SUCCESS, authResponse)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
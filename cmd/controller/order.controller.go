@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/authz"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// getOrders godoc
+// @Summary      Get orders
+// @Description  Get the orders the caller may read. Holders of orders:read get every order, holders of orders:read:own only their own.
+// @Tags         Orders
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=[]models.Order}
+// @Failure      403  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/orders [get]
+func (c *Controller) HttpGetOrders(w http.ResponseWriter, r *http.Request) {
+	orders, err := c.orderService.GetAll(r.Context(), authz.SubjectFromContext(r.Context()))
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.ORDER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.ORDER, http.StatusOK, orders)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getOrder godoc
+// @Summary      Get order
+// @Description  Get an order. Orders of other customers are reported as not found to holders of orders:read:own.
+// @Tags         Orders
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      200  {object} models.Response{data=models.Order}
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/orders/{id} [get]
+func (c *Controller) HttpGetOrder(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	order, err := c.orderService.GetById(r.Context(), authz.SubjectFromContext(r.Context()), id)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.ORDER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.ORDER, http.StatusOK, order)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// cancelOrder godoc
+// @Summary      Cancel order
+// @Description  Cancel a pending or paid order. Holders of orders:cancel can cancel any order, holders of orders:cancel:own only their own.
+// @Tags         Orders
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Order ID"
+// @Success      202  {object} models.Response{data=models.Order}
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/orders/{id}/cancel [post]
+func (c *Controller) HttpCancelOrder(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	order, err := c.orderService.Cancel(r.Context(), authz.SubjectFromContext(r.Context()), id)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.ORDER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.ORDER, http.StatusAccepted, order)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
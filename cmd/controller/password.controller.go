@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// forgotPassword godoc
+// @Summary      Forgot password
+// @Description  Email a password reset link. The response is the same whether or not the email exists.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ForgotPasswordRequest true "Email"
+// @Success      200  {object} models.Response
+// @Failure      400  {object} models.Response
+// @Router       /api/v1/auth/password/forgot [post]
+func (c *Controller) HttpForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var request models.ForgotPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	c.passwordService.Forgot(r.Context(), request.Email)
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.PASSWORD_RESET_SENT, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// resetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password with a reset token. Every session of the user is revoked.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ResetPasswordRequest true "Reset token and new password"
+// @Success      200  {object} models.Response
+// @Failure      400  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/password/reset [post]
+func (c *Controller) HttpResetPassword(w http.ResponseWriter, r *http.Request) {
+	var request models.ResetPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if violations := utils.ValidatePassword(request.Password); len(violations) > 0 {
+		response := &models.Response{
+			Success: false,
+			Message: "Password does not meet the password policy.",
+			Code:    http.StatusBadRequest,
+			Errors:  violations,
+		}
+		if err := utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := c.passwordService.Reset(r.Context(), request.Token, request.Password); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.PASSWORD_RESET, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
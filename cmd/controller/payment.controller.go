@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/authz"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// getPayments godoc
+// @Summary      Get payments
+// @Description  Get the payments the caller may read. Holders of payments:read get every payment, holders of payments:read:own those of their own orders.
+// @Tags         Payments
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=[]models.Payment}
+// @Failure      403  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/payments [get]
+func (c *Controller) HttpGetPayments(w http.ResponseWriter, r *http.Request) {
+	payments, err := c.paymentService.GetAll(r.Context(), authz.SubjectFromContext(r.Context()))
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PAYMENT, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PAYMENT, http.StatusOK, payments)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getPayment godoc
+// @Summary      Get payment
+// @Description  Get a payment. Payments of other customers are reported as not found to holders of payments:read:own.
+// @Tags         Payments
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "Payment ID"
+// @Success      200  {object} models.Response{data=models.Payment}
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/payments/{id} [get]
+func (c *Controller) HttpGetPayment(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	payment, err := c.paymentService.GetById(r.Context(), authz.SubjectFromContext(r.Context()), id)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PAYMENT, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PAYMENT, http.StatusOK, payment)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
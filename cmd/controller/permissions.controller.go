@@ -10,7 +10,7 @@ import (
 
 // getPermissions godoc
 // @Summary      Get  permissions
-// @Description  Get all permissions
+// @Description  Get all permissions grouped as in the catalog, with their description and danger level
 // @Tags         Roles and Permissions
 // @Security     BearerAuth
 // @Accept       json
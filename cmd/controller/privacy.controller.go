@@ -0,0 +1,389 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// requestMyExport godoc
+// @Summary      Request my data export
+// @Description  Start packaging the profile, orders, payments and customer records of the signed in user into a ZIP archive of JSON files. An email is sent once it can be downloaded.
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Produce      json
+// @Success      202  {object} models.Response{data=models.DataExport}
+// @Failure      401  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/exports [post]
+func (c *Controller) HttpRequestMyExport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	export, err := c.privacyService.RequestExport(r.Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PRIVACY, codes.DATA_EXPORT_REQUESTED, export)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getMyExports godoc
+// @Summary      Get my data exports
+// @Description  List the data exports of the signed in user, newest first
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=[]models.DataExport}
+// @Failure      401  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/exports [get]
+func (c *Controller) HttpGetMyExports(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	exports, err := c.privacyService.ListExports(r.Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PRIVACY, http.StatusOK, exports)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// downloadMyExport godoc
+// @Summary      Download my data export
+// @Description  Download the ZIP archive of a ready data export of the signed in user
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Param        exportId  path  string  true  "Export ID"
+// @Produce      application/zip
+// @Success      200  {file}   file
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/exports/{exportId}/download [get]
+func (c *Controller) HttpDownloadMyExport(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	export, err := c.privacyService.DownloadExport(r.Context(), userID, mux.Vars(r)["exportId"])
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "data-export-"+export.ID+".zip"))
+	w.Header().Set("Content-Length", strconv.Itoa(len(export.Archive)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(export.Archive)
+}
+
+// requestMyErasure godoc
+// @Summary      Request erasure of my account
+// @Description  Schedule the erasure of the signed in user after the cooling-off period. Personal data is anonymized while orders and payments are kept for accounting. The request can be cancelled until the scheduled date.
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.RequestErasureRequest  true  "Current password"
+// @Success      202  {object} models.Response{data=models.ErasureRequest}
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.ErrResponse
+// @Failure      403  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/erasure [post]
+func (c *Controller) HttpRequestMyErasure(w http.ResponseWriter, r *http.Request) {
+	var request models.RequestErasureRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	erasure, err := c.privacyService.RequestErasure(r.Context(), userID, &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PRIVACY, codes.ERASURE_SCHEDULED, erasure)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// getMyErasure godoc
+// @Summary      Get my erasure request
+// @Description  Get the latest erasure request of the signed in user
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=models.ErasureRequest}
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/erasure [get]
+func (c *Controller) HttpGetMyErasure(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	erasure, err := c.privacyService.GetErasure(r.Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PRIVACY, http.StatusOK, erasure)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// cancelMyErasure godoc
+// @Summary      Cancel erasure of my account
+// @Description  Cancel the pending erasure of the signed in user
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/erasure [delete]
+func (c *Controller) HttpCancelMyErasure(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	err := c.privacyService.CancelErasure(r.Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PRIVACY, codes.ERASURE_CANCELLED, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// eraseUser godoc
+// @Summary      Erase user
+// @Description  Schedule the erasure of a user after the cooling-off period, or with immediate set erase them right away. The caller must hold every permission of the user
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "User ID"
+// @Param        request  body      models.AdminErasureRequest  true  "Reason and override"
+// @Success      200  {object} models.Response{data=models.ErasureRequest}
+// @Success      202  {object} models.Response{data=models.ErasureRequest}
+// @Failure      400  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id}/erasure [post]
+func (c *Controller) HttpEraseUser(w http.ResponseWriter, r *http.Request) {
+	var request models.AdminErasureRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	erasure, err := c.privacyService.AdminErase(r.Context(), mux.Vars(r)["id"], &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	code := codes.ERASURE_SCHEDULED
+	if request.Immediate {
+		code = codes.USER_ERASED
+	}
+
+	resp := utils.GenSuccessResponse(entities.PRIVACY, code, erasure)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// cancelUserErasure godoc
+// @Summary      Cancel user erasure
+// @Description  Cancel the pending erasure of a user
+// @Tags         Privacy
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Produce      json
+// @Success      200  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id}/erasure [delete]
+func (c *Controller) HttpCancelUserErasure(w http.ResponseWriter, r *http.Request) {
+	err := c.privacyService.CancelErasure(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.PRIVACY, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.PRIVACY, codes.ERASURE_CANCELLED, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/service"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// getMe godoc
+// @Summary      Get my profile
+// @Description  Get the profile of the signed in user
+// @Tags         Profile
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=models.User}
+// @Failure      401  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me [get]
+func (c *Controller) HttpGetMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	user, err := c.userService.GetById(r.Context(), userID)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, http.StatusOK, user)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// updateMe godoc
+// @Summary      Update my profile
+// @Description  Change the name or birthday of the signed in user. Fields left out are not changed.
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.UpdateProfileRequest  true  "Profile fields"
+// @Success      200  {object} models.Response{data=models.User}
+// @Failure      400  {object} models.ErrResponse
+// @Failure      401  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me [patch]
+func (c *Controller) HttpUpdateMe(w http.ResponseWriter, r *http.Request) {
+	var request models.UpdateProfileRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	user, err := c.profileService.Update(r.Context(), userID, &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.PROFILE_UPDATED, user)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// changePassword godoc
+// @Summary      Change my password
+// @Description  Change the password of the signed in user. The current password is required and every session is signed out.
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ChangePasswordRequest  true  "Current and new password"
+// @Success      200  {object} models.Response
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.ErrResponse
+// @Failure      403  {object} models.ErrResponse
+// @Failure      429  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/password [post]
+func (c *Controller) HttpChangePassword(w http.ResponseWriter, r *http.Request) {
+	var request models.ChangePasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if violations := utils.ValidatePassword(request.NewPassword); len(violations) > 0 {
+		response := &models.Response{
+			Success: false,
+			Message: "Password does not meet the password policy.",
+			Code:    http.StatusBadRequest,
+			Errors:  violations,
+		}
+		if err := utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	if err := c.profileService.ChangePassword(r.Context(), userID, &request); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			var throttled *service.LoginThrottledError
+			if errors.As(appErr.Err, &throttled) {
+				w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			}
+
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.PASSWORD_CHANGED, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// changeEmail godoc
+// @Summary      Change my email
+// @Description  Request a change of email. The new email must be confirmed with the link sent to it before it is used.
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.ChangeEmailRequest  true  "New email and current password"
+// @Success      202  {object} models.Response
+// @Failure      400  {object} models.ErrResponse
+// @Failure      401  {object} models.ErrResponse
+// @Failure      403  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
+// @Failure      429  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/email [post]
+func (c *Controller) HttpChangeEmail(w http.ResponseWriter, r *http.Request) {
+	var request models.ChangeEmailRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	userID := r.Context().Value(constants.USER_ID_KEY).(string)
+
+	if err := c.profileService.ChangeEmail(r.Context(), userID, &request); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			var throttled *service.LoginThrottledError
+			if errors.As(appErr.Err, &throttled) {
+				w.Header().Set("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			}
+
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.EMAIL_CHANGE_REQUESTED, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
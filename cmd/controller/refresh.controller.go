@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// refreshToken godoc
+// @Summary      Refresh tokens
+// @Description  Exchange a refresh token for a new access token and refresh token. Refresh tokens are single use.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.RefreshRequest true "Refresh token"
+// @Success      200  {object} models.AuthResponse
+// @Failure      400  {object} models.Response
+// @Failure      401  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/refresh [post]
+func (c *Controller) HttpRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var request models.RefreshRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	authResponse, err := c.authService.Refresh(r.Context(), request.RefreshToken)
+
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.AUTHORIZATION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.TOKEN_REFRESHED, authResponse)
+
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
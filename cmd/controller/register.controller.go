@@ -8,6 +8,7 @@ import (
 
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
 )
 
@@ -47,13 +48,14 @@ func (c *Controller) HttpRegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := utils.ValidatePassword(user.Password); err != nil {
+	if violations := utils.ValidatePassword(user.Password); len(violations) > 0 {
 		response := &models.Response{
 			Success: false,
-			Message: err.Error(),
+			Message: "Password does not meet the password policy.",
 			Code:    http.StatusBadRequest,
+			Errors:  violations,
 		}
-		if err = utils.SendResponse(w, response); err != nil {
+		if err := utils.SendResponse(w, response); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 		return
@@ -78,18 +80,39 @@ func (c *Controller) HttpRegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := utils.GenerateJWT(createdUser.ID, createdUser.Roles)
+	// A failed email must not fail the registration, the user can ask for a new link
+	if err := c.verificationService.SendVerification(r.Context(), createdUser); err != nil {
+		logger.FromContext(r.Context()).ErrLogger.ErrorContext(r.Context(), err.Error(), "entity", entities.USER)
+	}
+
+	// Unverified accounts cannot sign in, so there is no session to hand out yet
+	if c.verificationService.Required() {
+		resp := utils.GenSuccessResponse(entities.USER, http.StatusCreated, models.AuthResponse{User: *createdUser})
+		if err := utils.SendResponse(w, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	authResponse, err := c.authService.IssueTokens(r.Context(), createdUser)
 
 	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Success response
-	resp := utils.GenSuccessResponse(entities.USER, http.StatusCreated, models.AuthResponse{
-		Token: token,
-		User:  *createdUser,
-	})
+	resp := utils.GenSuccessResponse(entities.USER, http.StatusCreated, authResponse)
 
 	if err := utils.SendResponse(w, resp); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
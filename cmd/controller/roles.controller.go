@@ -17,12 +17,12 @@ const Role = entities.ROLE
 
 // getRoles godoc
 // @Summary      Get role
-// @Description  Get a role with it permissions
+// @Description  Get a role with its direct permissions and the permissions it inherits from its parent roles
 // @Tags         Roles and Permissions
 // @Security     BearerAuth
 // @Param 		 id   path      string  true  "Role ID"
 // @Produce      json
-// @Success      200  {object} models.Response{data=models.Role}
+// @Success      200  {object} models.Response{data=models.RoleDetail}
 // @Failure      400  {object} models.ErrResponse
 // @Failure      500  {object} models.ErrResponse
 // @Router       /api/v1/roles/{id} [get]
@@ -100,7 +100,7 @@ func (c *Controller) HttpGetAllRoles(w http.ResponseWriter, r *http.Request) {
 
 // createRole godoc
 // @Summary      Create role
-// @Description  Create a role with its permissions
+// @Description  Create a role with its permissions and the roles it inherits from
 // @Tags         Roles and Permissions
 // @Security     BearerAuth
 // @Accept       json
@@ -151,7 +151,7 @@ func (c *Controller) HttpCreateRole(w http.ResponseWriter, r *http.Request) {
 
 // updateRole godoc
 // @Summary      Update role
-// @Description  Update a role with its permissions
+// @Description  Update a role with its permissions and the roles it inherits from. Parents that would make the role inherit from itself are refused with 409
 // @Tags         Roles and Permissions
 // @Security     BearerAuth
 // @Accept       json
@@ -160,6 +160,7 @@ func (c *Controller) HttpCreateRole(w http.ResponseWriter, r *http.Request) {
 // @Param        request  body      models.RoleRequest  true  "Role data"
 // @Success      200  {object} models.Response{data=models.Role}
 // @Failure      400  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
 // @Failure      500  {object} models.ErrResponse
 // @Router       /api/v1/roles/{id} [put]
 func (c *Controller) HttpUpdateRole(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// getMySessions godoc
+// @Summary      Get my sessions
+// @Description  List the devices the current user is signed in on. The session making the request is marked as current.
+// @Tags         Sessions
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} models.Response{data=[]models.Session}
+// @Failure      401  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/sessions [get]
+func (c *Controller) HttpGetMySessions(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(constants.CLAIMS_KEY).(*utils.Claims)
+
+	c.sendSessions(w, r, claims.UserID, claims.SessionID)
+}
+
+// revokeMySession godoc
+// @Summary      Revoke my session
+// @Description  Sign the current user out of one device
+// @Tags         Sessions
+// @Security     BearerAuth
+// @Param        sid  path      string  true  "Session ID"
+// @Produce      json
+// @Success      200  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/me/sessions/{sid} [delete]
+func (c *Controller) HttpRevokeMySession(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(constants.CLAIMS_KEY).(*utils.Claims)
+
+	c.revokeSession(w, r, claims.UserID, mux.Vars(r)["sid"])
+}
+
+// getUserSessions godoc
+// @Summary      Get user sessions
+// @Description  List the devices a user is signed in on
+// @Tags         Sessions
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Produce      json
+// @Success      200  {object} models.Response{data=[]models.Session}
+// @Failure      403  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id}/sessions [get]
+func (c *Controller) HttpGetUserSessions(w http.ResponseWriter, r *http.Request) {
+	c.sendSessions(w, r, mux.Vars(r)["id"], "")
+}
+
+// revokeUserSession godoc
+// @Summary      Revoke user session
+// @Description  Sign a user out of one device. The caller must hold every permission of the user.
+// @Tags         Sessions
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Param        sid  path      string  true  "Session ID"
+// @Produce      json
+// @Success      200  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id}/sessions/{sid} [delete]
+func (c *Controller) HttpRevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	c.revokeSession(w, r, vars["id"], vars["sid"])
+}
+
+func (c *Controller) sendSessions(w http.ResponseWriter, r *http.Request, userID, currentSID string) {
+	sessions, err := c.sessionService.List(r.Context(), userID, currentSID)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.SESSION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.SESSION, http.StatusOK, sessions)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (c *Controller) revokeSession(w http.ResponseWriter, r *http.Request, userID, sessionID string) {
+	if err := c.sessionService.Revoke(r.Context(), userID, sessionID); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.SESSION, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.SESSION, http.StatusNoContent, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,9 +1,13 @@
 package controller
 
 import (
+	"encoding/json"
 	"net/http"
 
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
 	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
 	"github.com/gorilla/mux"
@@ -87,3 +91,330 @@ func (c *Controller) HttpGetUserById(w http.ResponseWriter, r *http.Request) {
 	}
 
 }
+
+// unlockUser godoc
+// @Summary      Unlock user
+// @Description  Clear the failed login counter and lockout of a user. The caller must hold every permission of the user.
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  models.Response
+// @Failure      403  {object}  models.ErrResponse
+// @Failure      404  {object}  models.ErrResponse
+// @Failure      500  {object}  models.ErrResponse
+// @Router       /api/v1/users/{id}/unlock [post]
+func (c *Controller) HttpUnlockUser(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := c.lockoutService.Unlock(r.Context(), id); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.ACCOUNT_UNLOCKED, nil)
+	if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+		http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+	}
+}
+
+// createUser godoc
+// @Summary      Create user
+// @Description  Create a staff user with a temporary password. The email counts as verified and the user holds no permissions until the password is changed. Roles default to "user", and only roles whose permissions the caller holds can be given.
+// @Tags         Users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.CreateUserRequest  true  "User details"
+// @Success      201  {object} models.Response{data=models.CreatedUserResponse}
+// @Failure      400  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users [post]
+func (c *Controller) HttpCreateUser(w http.ResponseWriter, r *http.Request) {
+	var request models.CreateUserRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	permissions := r.Context().Value(constants.PERMISSIONS_KEY).([]string)
+
+	created, err := c.userService.AdminCreate(r.Context(), permissions, &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.USER_CREATED, created)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// updateUser godoc
+// @Summary      Update user
+// @Description  Change the profile fields of a user. Fields left out are not changed. A new email applies right away, signs the user out and is reported to the old address. The caller must hold every permission of the user.
+// @Tags         Users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "User ID"
+// @Param        request  body      models.UpdateUserRequest  true  "User fields"
+// @Success      202  {object} models.Response{data=models.User}
+// @Failure      400  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      409  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id} [patch]
+func (c *Controller) HttpUpdateUser(w http.ResponseWriter, r *http.Request) {
+	var request models.UpdateUserRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	user, err := c.userService.Update(r.Context(), id, &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, http.StatusAccepted, user)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// deleteUser godoc
+// @Summary      Delete user
+// @Description  Soft delete a user and sign them out everywhere. The caller must hold every permission of the user. Whether their email can be used again is set by ALLOW_DELETED_EMAIL_REUSE.
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object} models.Response
+// @Failure      400  {object} models.ErrResponse
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id} [delete]
+func (c *Controller) HttpDeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	err := c.userService.Delete(r.Context(), id)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, http.StatusNoContent, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// assignUserRole godoc
+// @Summary      Assign role
+// @Description  Give a user a role by ID or name, optionally until expires_at. The caller must hold every permission of the role and of the user. Expired grants are removed and the user signed out by a background sweeper.
+// @Tags         Users
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true  "User ID"
+// @Param        request  body      models.AssignRoleRequest  true  "Role ID or name"
+// @Success      200  {object} models.Response{data=models.User}
+// @Failure      400  {object} models.Response
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id}/roles [post]
+func (c *Controller) HttpAssignUserRole(w http.ResponseWriter, r *http.Request) {
+	var request models.AssignRoleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		resp := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	granterID, _ := r.Context().Value(constants.USER_ID_KEY).(string)
+	permissions := r.Context().Value(constants.PERMISSIONS_KEY).([]string)
+
+	user, err := c.userService.AssignRole(r.Context(), id, granterID, permissions, &request)
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.ROLE_ASSIGNED, user)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// removeUserRole godoc
+// @Summary      Remove role
+// @Description  Take a role away from a user. The caller must hold every permission of the role and of the user.
+// @Tags         Users
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id      path      string  true  "User ID"
+// @Param        roleId  path      string  true  "Role ID or name"
+// @Success      200  {object} models.Response{data=models.User}
+// @Failure      403  {object} models.ErrResponse
+// @Failure      404  {object} models.ErrResponse
+// @Failure      500  {object} models.ErrResponse
+// @Router       /api/v1/users/{id}/roles/{roleId} [delete]
+func (c *Controller) HttpRemoveUserRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	permissions := r.Context().Value(constants.PERMISSIONS_KEY).([]string)
+
+	user, err := c.userService.RemoveRole(r.Context(), vars["id"], permissions, vars["roleId"])
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.ROLE_REMOVED, user)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
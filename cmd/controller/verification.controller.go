@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// verifyEmail godoc
+// @Summary      Verify email
+// @Description  Activate an account with the token from the verification email
+// @Tags         Auth
+// @Produce      json
+// @Param        token query     string  true  "Verification token"
+// @Success      200  {object} models.Response{data=models.User}
+// @Failure      401  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/verify [get]
+func (c *Controller) HttpVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	user, err := c.verificationService.Verify(r.Context(), token)
+
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.EMAIL_VERIFIED, user)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// resendVerification godoc
+// @Summary      Resend verification email
+// @Description  Send a new verification link. The response is the same whether or not the email exists.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body models.ResendVerificationRequest true "Email"
+// @Success      200  {object} models.Response
+// @Failure      400  {object} models.Response
+// @Failure      500  {object} models.Response
+// @Router       /api/v1/auth/verify/resend [post]
+func (c *Controller) HttpResendVerification(w http.ResponseWriter, r *http.Request) {
+	var request models.ResendVerificationRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response := utils.GenErrorResponse(entities.USER, http.StatusBadRequest, err)
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		response := &models.Response{
+			Success: false,
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+
+		if err = utils.SendResponse(w, response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if err := c.verificationService.Resend(r.Context(), request.Email); err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			resp := utils.GenErrorResponse(appErr.Entity, appErr.Code, appErr.Err)
+			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			}
+
+			return
+		}
+
+		resp := utils.GenErrorResponse(entities.USER, http.StatusInternalServerError, err)
+		if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+			http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	resp := utils.GenSuccessResponse(entities.USER, codes.VERIFICATION_SENT, nil)
+	if err := utils.SendResponse(w, resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -25,16 +25,18 @@ import (
 // @BasePath  /api/v1
 
 type application struct {
-	port   int
-	router http.Handler
+	port     int
+	router   http.Handler
+	shutdown func()
 }
 
 func main() {
 	env.LoadEnv()
-	router := config.Setup()
+	router, shutdown := config.Setup()
 	app := application{
-		port:   env.GetIntEnv("PORT", 3000),
-		router: router,
+		port:     env.GetIntEnv("PORT", 3000),
+		router:   router,
+		shutdown: shutdown,
 	}
 
 	app.Serve()
@@ -5,64 +5,128 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/service"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
 	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
 )
 
 const (
 	AuthHeader     = "Authorization"
+	APIKeyHeader   = "X-API-Key"
 	RequestIdKey   = constants.REQUEST_ID_KEY
 	UserIDKey      = constants.USER_ID_KEY
 	TraceIDKey     = constants.TRACE_ID_KEY
 	PermissionsKey = constants.PERMISSIONS_KEY
+	ClaimsKey      = constants.CLAIMS_KEY
+	ClientIPKey    = constants.CLIENT_IP_KEY
+	APIKeyIDKey    = constants.API_KEY_ID_KEY
+	UserAgentKey   = constants.USER_AGENT_KEY
+	ActorIDKey     = constants.ACTOR_ID_KEY
 )
 
-func AuthMiddleWare(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get(AuthHeader)
+// AuthMiddleWare authenticates the request with a Bearer access token, or with an
+// X-API-Key header when apiKeys is set. API keys only carry permissions, no user ID.
+func AuthMiddleWare(auth *service.AuthService, apiKeys *service.APIKeyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rawKey := r.Header.Get(APIKeyHeader); rawKey != "" && apiKeys != nil {
+				key, err := apiKeys.Authenticate(r.Context(), rawKey)
 
-		if authHeader == "" {
-			resp := utils.GenAuthResponse(codes.NO_TOKEN_PROVIDED, http.StatusUnauthorized)
-			if err := utils.SendResponse(w, resp); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				if err != nil {
+					code := codes.INVALID_API_KEY
+					if ae, ok := err.(*appErrors.AppError); ok && ae.Entity == entities.AUTHORIZATION {
+						code = ae.Code
+					}
+
+					resp := utils.GenAuthResponse(code, codes.HTTPStatus(code))
+					if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+						http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+					}
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), APIKeyIDKey, key.ID)
+				ctx = context.WithValue(ctx, PermissionsKey, key.Permissions)
+				setIdentity(ctx, "", "", key.ID)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
 			}
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			resp := utils.GenAuthResponse(codes.INVALID_TOKEN, http.StatusUnauthorized)
-			if err := utils.SendResponse(w, resp); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			authHeader := r.Header.Get(AuthHeader)
+
+			if authHeader == "" {
+				resp := utils.GenAuthResponse(codes.NO_TOKEN_PROVIDED, http.StatusUnauthorized)
+				if err := utils.SendResponse(w, resp); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
 			}
-			return
-		}
 
-		tokenStr := parts[1]
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				resp := utils.GenAuthResponse(codes.INVALID_TOKEN, http.StatusUnauthorized)
+				if err := utils.SendResponse(w, resp); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+
+			tokenStr := parts[1]
 
-		userid, permissions, err := utils.VerifyJWT(tokenStr)
+			claims, err := auth.Authenticate(r.Context(), tokenStr)
+
+			if err != nil {
+				if ae, ok := err.(*appErrors.AppError); ok {
+					resp := utils.GenAuthResponse(ae.Code, codes.HTTPStatus(ae.Code))
+					if sendErr := utils.SendResponse(w, resp); sendErr != nil {
+						http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+					}
+					return
+				}
+				// Fallback unexpected error: treat as invalid token
+				resp := utils.GenAuthResponse(codes.INVALID_TOKEN, http.StatusUnauthorized)
 
-		if err != nil {
-			if ae, ok := err.(*appErrors.AppError); ok {
-				resp := utils.GenAuthResponse(ae.Code, http.StatusUnauthorized)
 				if sendErr := utils.SendResponse(w, resp); sendErr != nil {
 					http.Error(w, sendErr.Error(), http.StatusInternalServerError)
 				}
 				return
 			}
-			// Fallback unexpected error: treat as invalid token
-			resp := utils.GenAuthResponse(codes.INVALID_TOKEN, http.StatusUnauthorized)
 
-			if sendErr := utils.SendResponse(w, resp); sendErr != nil {
-				http.Error(w, sendErr.Error(), http.StatusInternalServerError)
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, PermissionsKey, claims.Permissions)
+			ctx = context.WithValue(ctx, ClaimsKey, claims)
+			actorID := ""
+			if claims.Actor != nil {
+				actorID = claims.Actor.Subject
+				ctx = context.WithValue(ctx, ActorIDKey, actorID)
+			}
+			setIdentity(ctx, claims.UserID, actorID, "")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// MFAMiddleWare authenticates a regular access token or the mfa_pending token handed out
+// by login, so users forced into two-factor authentication can enroll before their first session.
+func MFAMiddleWare(auth *service.AuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := AuthMiddleWare(auth, nil)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parts := strings.Split(r.Header.Get(AuthHeader), " ")
+			if len(parts) == 2 && parts[0] == "Bearer" {
+				if claims, err := utils.VerifyActionToken(utils.PurposeMFA, parts[1]); err == nil {
+					ctx := context.WithValue(r.Context(), UserIDKey, claims.Subject)
+					setIdentity(ctx, claims.Subject, "", "")
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
 			}
-			return
-		}
 
-		ctx := context.WithValue(r.Context(), UserIDKey, userid)
-		ctx = context.WithValue(ctx, PermissionsKey, permissions)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			authenticated.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+)
+
+// trustedProxies are the networks of the proxies in front of the app, from
+// TRUSTED_PROXIES as a comma separated list of CIDRs. Forwarding headers are ignored
+// unless the request comes from one of them.
+var trustedProxies = sync.OnceValue(func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, raw := range strings.Split(env.GetStringEnv("TRUSTED_PROXIES", ""), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "/") {
+			if ip := net.ParseIP(raw); ip != nil && ip.To4() != nil {
+				raw += "/32"
+			} else {
+				raw += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(raw)
+		if err != nil {
+			log.Printf("⚠️ Ignoring TRUSTED_PROXIES entry %q: %v", raw, err)
+			continue
+		}
+		nets = append(nets, network)
+	}
+
+	return nets
+})
+
+func isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies() {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getClientIP returns the IP address of the client, in IPv4 format when possible.
+// Forwarding headers are set by the client unless a proxy overwrites them, so they
+// only count when the peer is a trusted proxy:
+//
+//   - CLIENT_IP_HEADER names a header the platform sets, such as Fly-Client-IP
+//   - otherwise the rightmost X-Forwarded-For entry that is not a trusted proxy
+//
+// Every other request is identified by its peer address.
+func getClientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// If SplitHostPort fails, RemoteAddr might not have a port
+		peer = r.RemoteAddr
+	}
+
+	ip := peer
+	if isTrustedProxy(peer) {
+		ip = forwardedClientIP(r, peer)
+	}
+
+	if ipv4 := parseIPv4(ip); ipv4 != "" {
+		return ipv4
+	}
+
+	// If no IPv4 found, return the original IP (could be IPv6 or invalid)
+	return ip
+}
+
+func forwardedClientIP(r *http.Request, peer string) string {
+	if header := env.GetStringEnv("CLIENT_IP_HEADER", ""); header != "" {
+		if ip := strings.TrimSpace(r.Header.Get(header)); net.ParseIP(ip) != nil {
+			return ip
+		}
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// Anything left of a malformed entry cannot be trusted either
+			return peer
+		}
+		if !isTrustedProxy(hop) {
+			return hop
+		}
+		peer = hop
+	}
+
+	return peer
+}
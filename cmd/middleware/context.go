@@ -20,6 +20,8 @@ func WithContext(next http.Handler) http.Handler {
 		requestId := uuid.NewString()
 		ctx := context.WithValue(r.Context(), TraceIDKey, traceId)
 		ctx = context.WithValue(ctx, RequestIdKey, requestId)
+		ctx = context.WithValue(ctx, ClientIPKey, getClientIP(r))
+		ctx = context.WithValue(ctx, UserAgentKey, r.UserAgent())
 
 		w.Header().Set("X-Trace-ID", traceId)
 		w.Header().Set("X-Request-ID", requestId)
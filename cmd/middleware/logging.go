@@ -36,51 +36,35 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
-// getClientIP extracts the client's real IP address in IPv4 format
-// Checks proxy headers (X-Forwarded-For, X-Real-IP) before falling back to RemoteAddr
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (common in reverse proxies/load balancers)
-	xForwardedFor := r.Header.Get("X-Forwarded-For")
-	if xForwardedFor != "" {
-		// X-Forwarded-For can contain multiple IPs, the first one is the original client
-		ips := strings.Split(xForwardedFor, ",")
-		if len(ips) > 0 {
-			ip := strings.TrimSpace(ips[0])
-			if ipv4 := parseIPv4(ip); ipv4 != "" {
-				return ipv4
-			}
-		}
-	}
+// requestIdentity is filled in by AuthMiddleWare so that RequestLogger, which wraps
+// authentication, can log who made the request.
+type requestIdentity struct {
+	userID   string
+	actorID  string
+	apiKeyID string
+}
 
-	// Check X-Real-IP header (alternative proxy header)
-	xRealIP := r.Header.Get("X-Real-IP")
-	if xRealIP != "" {
-		if ipv4 := parseIPv4(xRealIP); ipv4 != "" {
-			return ipv4
-		}
-	}
+const identityKey ctxKey = "request_identity"
 
-	// Check CF-Connecting-IP (Cloudflare)
-	cfIP := r.Header.Get("CF-Connecting-IP")
-	if cfIP != "" {
-		if ipv4 := parseIPv4(cfIP); ipv4 != "" {
-			return ipv4
-		}
+// setIdentity records the authenticated caller on the identity of the request, if any.
+func setIdentity(ctx context.Context, userID, actorID, apiKeyID string) {
+	if identity, ok := ctx.Value(identityKey).(*requestIdentity); ok {
+		identity.userID, identity.actorID, identity.apiKeyID = userID, actorID, apiKeyID
 	}
+}
 
-	// Fall back to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		// If SplitHostPort fails, RemoteAddr might not have a port
-		ip = r.RemoteAddr
+// withValues adds the recorded caller to ctx under the keys the logger reads.
+func (i *requestIdentity) withValues(ctx context.Context) context.Context {
+	if i.userID != "" {
+		ctx = context.WithValue(ctx, UserIDKey, i.userID)
 	}
-
-	if ipv4 := parseIPv4(ip); ipv4 != "" {
-		return ipv4
+	if i.actorID != "" {
+		ctx = context.WithValue(ctx, ActorIDKey, i.actorID)
 	}
-
-	// If no IPv4 found, return the original IP (could be IPv6 or invalid)
-	return ip
+	if i.apiKeyID != "" {
+		ctx = context.WithValue(ctx, APIKeyIDKey, i.apiKeyID)
+	}
+	return ctx
 }
 
 // parseIPv4 parses an IP address and returns it if it's IPv4, otherwise returns empty string
@@ -108,11 +92,14 @@ func RequestLogger(log *logger.AppLogger) func(http.Handler) http.Handler {
 			rw := newResponseWriter(w)
 
 			// Extract context values
+			identity := &requestIdentity{}
 			ctx := context.WithValue(r.Context(), constants.LOGGER_KEY, log)
+			ctx = context.WithValue(ctx, identityKey, identity)
 			// Process the request
 			next.ServeHTTP(rw, r.WithContext(ctx))
 
-			ctx = r.Context()
+			// Authentication runs further down the chain, so its values only come back through identity
+			ctx = identity.withValues(r.Context())
 			// Calculate request duration
 			duration := time.Since(start)
 
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/ratelimit"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// RateLimit counts every request against policy and rejects it once the limit is used
+// up. The RateLimit-* headers tell clients where they stand. Requests are let through
+// when the store fails, an outage of the limiter should not take the API down.
+func RateLimit(store ratelimit.Store, policy ratelimit.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if policy.Disabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := policy.Name + ":" + policy.Key(r)
+
+			result, err := store.Take(r.Context(), key, policy.Limit, time.Now())
+			if err != nil {
+				logger.FromContext(r.Context()).ErrLogger.ErrorContext(r.Context(), "rate limit store failed", "policy", policy.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit.Requests, ceilSeconds(policy.Limit.Window)))
+
+			if !result.Allowed {
+				header.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+
+				resp := utils.GenAuthResponse(codes.RATE_LIMITED, http.StatusTooManyRequests)
+				if err := utils.SendResponse(w, resp); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
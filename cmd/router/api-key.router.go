@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+func (r *Router) initializeAPIKeyRoutes(c *controller.Controller) {
+	apiKeyRouter := r.router.PathPrefix("/api-keys").Subrouter()
+
+	// Keys are managed by people, a key cannot mint further keys
+	apiKeyRouter.Use(r.session, r.limit("api-keys", apiLimit))
+	apiKeyRouter.HandleFunc("", utils.HandlePermissions(constants.ManageAPIKeys, c.HttpCreateAPIKey)).Methods("POST")
+	apiKeyRouter.HandleFunc("", utils.HandlePermissions(constants.ManageAPIKeys, c.HttpGetAPIKeys)).Methods("GET")
+	apiKeyRouter.HandleFunc("/{id}", utils.HandlePermissions(constants.ManageAPIKeys, c.HttpRevokeAPIKey)).Methods("DELETE")
+}
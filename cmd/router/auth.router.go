@@ -4,9 +4,33 @@ import "github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
 
 func (r *Router) initializeRegisterRoutes(c *controller.Controller) {
 	registerRouter := r.router.PathPrefix("/auth").Subrouter()
+	registerRouter.Use(r.limit("auth", authLimit))
 
 	registerRouter.HandleFunc("/login", c.HttpLoginUser).Methods("POST")
 
 	registerRouter.HandleFunc("/register", c.HttpRegisterUser).Methods("POST")
 
+	registerRouter.HandleFunc("/refresh", c.HttpRefreshToken).Methods("POST")
+
+	registerRouter.HandleFunc("/verify", c.HttpVerifyEmail).Methods("GET")
+	registerRouter.HandleFunc("/verify/resend", c.HttpResendVerification).Methods("POST")
+
+	registerRouter.HandleFunc("/password/forgot", c.HttpForgotPassword).Methods("POST")
+	registerRouter.HandleFunc("/password/reset", c.HttpResetPassword).Methods("POST")
+
+	registerRouter.HandleFunc("/mfa/verify", c.HttpVerifyMFA).Methods("POST")
+	registerRouter.HandleFunc("/oidc/{provider}/start", c.HttpOIDCStart).Methods("GET")
+	registerRouter.HandleFunc("/oidc/{provider}/callback", c.HttpOIDCCallback).Methods("GET")
+
+	enrollRoutes := registerRouter.PathPrefix("/mfa/enroll").Subrouter()
+	enrollRoutes.Use(r.mfa)
+	enrollRoutes.HandleFunc("", c.HttpEnrollMFA).Methods("POST")
+	enrollRoutes.HandleFunc("/confirm", c.HttpConfirmMFA).Methods("POST")
+
+	protectRoutes := registerRouter.NewRoute().Subrouter()
+	protectRoutes.Use(r.session)
+	protectRoutes.HandleFunc("/logout", c.HttpLogout).Methods("POST")
+	protectRoutes.HandleFunc("/logout-all", c.HttpLogoutAll).Methods("POST")
+	protectRoutes.HandleFunc("/mfa/disable", c.HttpDisableMFA).Methods("POST")
+	protectRoutes.HandleFunc("/mfa/recovery-codes", c.HttpRegenerateRecoveryCodes).Methods("POST")
 }
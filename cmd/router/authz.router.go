@@ -0,0 +1,13 @@
+package router
+
+import (
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
+)
+
+func (r *Router) initializeAuthzRoutes(c *controller.Controller) {
+	authzRouter := r.router.PathPrefix("/authz").Subrouter()
+
+	// Anyone may check themselves, the handler requires roles:manage for other users
+	authzRouter.Use(r.auth, r.limit("authz", apiLimit))
+	authzRouter.HandleFunc("/check", c.HttpCheckPermission).Methods("GET")
+}
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+// Routes only require the :own permissions, which the permissions on every record
+// imply. The services narrow the records through authz.
+func (r *Router) initializeOrderRoutes(c *controller.Controller) {
+	orderRouter := r.router.PathPrefix("/orders").Subrouter()
+
+	orderRouter.Use(r.auth, r.limit("orders", apiLimit))
+	orderRouter.HandleFunc("", utils.HandlePermissions(constants.ViewOwnOrders, c.HttpGetOrders)).Methods("GET")
+	orderRouter.HandleFunc("/{id}", utils.HandlePermissions(constants.ViewOwnOrders, c.HttpGetOrder)).Methods("GET")
+	orderRouter.HandleFunc("/{id}/cancel", utils.HandlePermissions(constants.CancelOwnOrder, c.HttpCancelOrder)).Methods("POST")
+}
+
+func (r *Router) initializePaymentRoutes(c *controller.Controller) {
+	paymentRouter := r.router.PathPrefix("/payments").Subrouter()
+
+	paymentRouter.Use(r.auth, r.limit("payments", apiLimit))
+	paymentRouter.HandleFunc("", utils.HandlePermissions(constants.ViewOwnPayments, c.HttpGetPayments)).Methods("GET")
+	paymentRouter.HandleFunc("/{id}", utils.HandlePermissions(constants.ViewOwnPayments, c.HttpGetPayment)).Methods("GET")
+}
@@ -1,30 +1,76 @@
 package router
 
 import (
+	"time"
+
 	"github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
 	"github.com/Aboagye-Dacosta/shopBackend/cmd/middleware"
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/service"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/ratelimit"
 	"github.com/gorilla/mux"
 )
 
+// Default rate limits, each can be overridden per route group with RATE_LIMIT_<GROUP>.
+var (
+	// globalLimit bounds every client IP, including requests that fail authentication
+	globalLimit = ratelimit.Policy{
+		Limit: ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Requests: 600, Window: time.Minute},
+		Key:   ratelimit.KeyByIP,
+	}
+	// authLimit guards sign in, registration and recovery against credential stuffing
+	authLimit = ratelimit.Policy{
+		Limit: ratelimit.Limit{Algorithm: ratelimit.TokenBucket, Requests: 20, Window: time.Minute},
+		Key:   ratelimit.KeyByIP,
+	}
+	// apiLimit applies to signed in users and API keys after authentication
+	apiLimit = ratelimit.Policy{
+		Limit: ratelimit.Limit{Algorithm: ratelimit.SlidingWindow, Requests: 300, Window: time.Minute},
+		Key:   ratelimit.KeyByUser,
+	}
+)
+
 type Router struct {
 	router *mux.Router
+	// auth accepts access tokens and API keys, session only accepts access tokens
+	auth    mux.MiddlewareFunc
+	session mux.MiddlewareFunc
+	mfa     mux.MiddlewareFunc
+	limits  ratelimit.Store
 }
 
-func InitRouter(c *controller.Controller, log *logger.AppLogger) *mux.Router {
+func InitRouter(c *controller.Controller, s *service.Service, limits ratelimit.Store, log *logger.AppLogger) *mux.Router {
 	root := mux.NewRouter()
 	r := root.PathPrefix("/api/v1").Subrouter()
 
 	r.Use(middleware.RecoverPanic(log))
 	r.Use(middleware.WithContext)
 	r.Use(middleware.RequestLogger(log))
+	r.Use(middleware.RateLimit(limits, ratelimit.PolicyFromEnv("global", globalLimit)))
 
-	appRouter := Router{r}
+	appRouter := Router{
+		router:  r,
+		auth:    middleware.AuthMiddleWare(s.AuthService, s.APIKeyService),
+		session: middleware.AuthMiddleWare(s.AuthService, nil),
+		mfa:     middleware.MFAMiddleWare(s.AuthService),
+		limits:  limits,
+	}
 	appRouter.initializeUserRoutes(c)
 	appRouter.initializeRegisterRoutes(c)
 	appRouter.initializePermissionsRoutes(c)
 	appRouter.initializeRolesRoutes(c)
+	appRouter.initializeAPIKeyRoutes(c)
+	appRouter.initializeOrderRoutes(c)
+	appRouter.initializePaymentRoutes(c)
+	appRouter.initializeAuthzRoutes(c)
 	appRouter.initializeDocsRoute(root)
+	appRouter.initializeWellKnownRoutes(root, c)
 
 	return root
 }
+
+// limit returns the rate limit middleware of a route group. Groups sharing a name share
+// their counters, so it has to run after authentication to count per user.
+func (r *Router) limit(group string, def ratelimit.Policy) mux.MiddlewareFunc {
+	return middleware.RateLimit(r.limits, ratelimit.PolicyFromEnv(group, def))
+}
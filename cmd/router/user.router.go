@@ -2,7 +2,6 @@ package router
 
 import (
 	"github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
-	"github.com/Aboagye-Dacosta/shopBackend/cmd/middleware"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
 )
@@ -10,9 +9,44 @@ import (
 func (r *Router) initializeUserRoutes(c *controller.Controller) {
 	userRouter := r.router.PathPrefix("/users").Subrouter()
 
+	// Registered before /{id} so "me" is not taken for a user ID
+	meRoutes := userRouter.PathPrefix("/me").Subrouter()
+	meRoutes.Use(r.session, r.limit("users", apiLimit))
+	meRoutes.HandleFunc("", c.HttpGetMe).Methods("GET")
+	meRoutes.HandleFunc("", c.HttpUpdateMe).Methods("PATCH")
+	meRoutes.HandleFunc("/password", c.HttpChangePassword).Methods("POST")
+	meRoutes.HandleFunc("/email", c.HttpChangeEmail).Methods("POST")
+	meRoutes.HandleFunc("/permissions", c.HttpGetMyPermissions).Methods("GET")
+	meRoutes.HandleFunc("/sessions", c.HttpGetMySessions).Methods("GET")
+	meRoutes.HandleFunc("/sessions/{sid}", c.HttpRevokeMySession).Methods("DELETE")
+	meRoutes.HandleFunc("/exports", c.HttpRequestMyExport).Methods("POST")
+	meRoutes.HandleFunc("/exports", c.HttpGetMyExports).Methods("GET")
+	meRoutes.HandleFunc("/exports/{exportId}/download", c.HttpDownloadMyExport).Methods("GET")
+	meRoutes.HandleFunc("/erasure", c.HttpRequestMyErasure).Methods("POST")
+	meRoutes.HandleFunc("/erasure", c.HttpGetMyErasure).Methods("GET")
+	meRoutes.HandleFunc("/erasure", c.HttpCancelMyErasure).Methods("DELETE")
+
+	// Impersonation needs a signed in user as actor, API keys are not accepted
+	sessionRoutes := userRouter.NewRoute().Subrouter()
+	sessionRoutes.Use(r.session, r.limit("users", apiLimit))
+	sessionRoutes.HandleFunc("/{id}/impersonate", utils.HandlePermissions(constants.ImpersonateUser, c.HttpImpersonateUser)).Methods("POST")
+
 	protectRoutes := userRouter.NewRoute().Subrouter()
-	protectRoutes.Use(middleware.AuthMiddleWare)
+	protectRoutes.Use(r.auth, r.limit("users", apiLimit))
 	protectRoutes.HandleFunc("", utils.HandlePermissions(constants.ViewUsers, c.HttpGetUsers)).Methods("GET")
+	protectRoutes.HandleFunc("", utils.HandlePermissions(constants.CreateUser, c.HttpCreateUser)).Methods("POST")
 	protectRoutes.HandleFunc("/{id}", utils.HandlePermissions(constants.ViewUsers, c.HttpGetUserById)).Methods("GET")
+	protectRoutes.HandleFunc("/{id}", utils.HandlePermissions(constants.UpdateUser, c.HttpUpdateUser)).Methods("PATCH")
+	protectRoutes.HandleFunc("/{id}", utils.HandlePermissions(constants.DeleteUser, c.HttpDeleteUser)).Methods("DELETE")
+	protectRoutes.HandleFunc("/{id}/roles", utils.HandlePermissions(constants.UpdateUser, c.HttpAssignUserRole)).Methods("POST")
+	protectRoutes.HandleFunc("/{id}/roles/{roleId}", utils.HandlePermissions(constants.UpdateUser, c.HttpRemoveUserRole)).Methods("DELETE")
+	protectRoutes.HandleFunc("/{id}/unlock", utils.HandlePermissions(constants.UpdateUser, c.HttpUnlockUser)).Methods("POST")
+	protectRoutes.HandleFunc("/{id}/suspend", utils.HandlePermissions(constants.BanUser, c.HttpSuspendUser)).Methods("POST")
+	protectRoutes.HandleFunc("/{id}/unsuspend", utils.HandlePermissions(constants.BanUser, c.HttpUnsuspendUser)).Methods("POST")
+	protectRoutes.HandleFunc("/{id}/suspensions", utils.HandlePermissions(constants.BanUser, c.HttpGetSuspensionHistory)).Methods("GET")
+	protectRoutes.HandleFunc("/{id}/sessions", utils.HandlePermissions(constants.UpdateUser, c.HttpGetUserSessions)).Methods("GET")
+	protectRoutes.HandleFunc("/{id}/sessions/{sid}", utils.HandlePermissions(constants.UpdateUser, c.HttpRevokeUserSession)).Methods("DELETE")
+	protectRoutes.HandleFunc("/{id}/erasure", utils.HandlePermissions(constants.DeleteUser, c.HttpEraseUser)).Methods("POST")
+	protectRoutes.HandleFunc("/{id}/erasure", utils.HandlePermissions(constants.DeleteUser, c.HttpCancelUserErasure)).Methods("DELETE")
 
 }
@@ -0,0 +1,11 @@
+package router
+
+import (
+	"github.com/Aboagye-Dacosta/shopBackend/cmd/controller"
+	"github.com/gorilla/mux"
+)
+
+func (r *Router) initializeWellKnownRoutes(root *mux.Router, c *controller.Controller) {
+	wellKnown := root.PathPrefix("/.well-known").Subrouter()
+	wellKnown.HandleFunc("/jwks.json", c.HttpJWKS).Methods("GET")
+}
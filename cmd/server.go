@@ -47,6 +47,9 @@ func (app *application) Serve() {
 		log.Printf("Server forced to shutdown: %v\n", err)
 	}
 
+	// Stop background jobs
+	app.shutdown()
+
 	// Close the logger file
 	if err := logger.Close(); err != nil {
 		log.Printf("Error closing log file: %v\n", err)
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const APIKey = entities.API_KEY
+
+// apiKeyPrefix marks our keys so they are easy to recognise in logs and secret scanners.
+const apiKeyPrefix = "sbk_"
+
+// apiKeyTouchInterval limits how often last_used_at is written for a busy key.
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyService struct {
+	keys        *models.APIKeyModel
+	permissions *PermissionService
+}
+
+// Create issues a new API key limited to req.Permissions. The caller can only grant
+// permissions they hold themselves. The plain key is returned once and never stored.
+func (s *APIKeyService) Create(ctx context.Context, creatorID string, creatorPermissions []string, req *models.CreateAPIKeyRequest) (*models.APIKeyCreatedResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, appErrors.New(APIKey, http.StatusBadRequest, errors.New("expires_at must be in the future"))
+	}
+
+	requested := normalizePermissions(req.Permissions)
+	for _, perm := range requested {
+		if !utils.HasPermission(creatorPermissions, constants.Permission(perm)) {
+			return nil, appErrors.New(APIKey, http.StatusForbidden, fmt.Errorf("cannot grant %q without holding it", perm))
+		}
+	}
+
+	var known []string
+	if err := s.keys.DB.WithContext(ctx).
+		Model(&models.Permission{}).
+		Pluck("name", &known).Error; err != nil {
+		return nil, appErrors.FromDb(APIKey, err)
+	}
+	for _, perm := range requested {
+		if !coversKnownPermission(perm, known) {
+			return nil, appErrors.New(APIKey, http.StatusBadRequest, fmt.Errorf("unknown permission %q requested", perm))
+		}
+	}
+
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	raw := apiKeyPrefix + secret
+
+	key := models.APIKey{
+		Name:        req.Name,
+		Prefix:      raw[:len(apiKeyPrefix)+8],
+		KeyHash:     utils.HashToken(raw),
+		Permissions: requested,
+		AllowedIPs:  req.AllowedIPs,
+		ExpiresAt:   req.ExpiresAt,
+		CreatedBy:   creatorID,
+	}
+
+	if err := s.keys.DB.WithContext(ctx).Create(&key).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", APIKey)
+		return nil, appErrors.FromDb(APIKey, err)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "API key created", "apiKeyID", key.ID, "permissions", key.Permissions)
+	return &models.APIKeyCreatedResponse{APIKey: key, Key: raw}, nil
+}
+
+func (s *APIKeyService) GetAll(ctx context.Context) ([]*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	keys := make([]*models.APIKey, 0)
+	if err := s.keys.DB.WithContext(ctx).Order("created_at DESC").Find(&keys).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", APIKey)
+		return nil, appErrors.FromDb(APIKey, err)
+	}
+
+	return keys, nil
+}
+
+// Revoke disables an API key immediately.
+func (s *APIKeyService) Revoke(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	result := s.keys.DB.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.ErrLogger.ErrorContext(ctx, result.Error.Error(), "entity", APIKey)
+		return appErrors.FromDb(APIKey, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.FromDb(APIKey, gorm.ErrRecordNotFound)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "API key revoked", "apiKeyID", id)
+	return nil
+}
+
+// Authenticate returns the API key matching raw when it is active and used from an allowed IP.
+// The IP is the one the request middleware resolved, which only honors forwarding headers
+// set by a trusted proxy, so a client cannot claim an allowlisted address.
+// A key never grants more than its creator currently holds, and stops working once the
+// creator is suspended or deleted.
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var key models.APIKey
+	if err := s.keys.DB.WithContext(ctx).
+		Where("key_hash = ?", utils.HashToken(raw)).
+		First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.NewAuth(codes.INVALID_API_KEY, err)
+		}
+		return nil, appErrors.FromDb(APIKey, err)
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, appErrors.NewAuth(codes.INVALID_API_KEY, errors.New("api key revoked or expired"))
+	}
+
+	if ip := clientIP(ctx); !ipAllowed(ip, key.AllowedIPs) {
+		return nil, appErrors.NewAuth(codes.API_KEY_IP_NOT_ALLOWED, fmt.Errorf("client ip %s not in allowlist", ip))
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.keys.DB.WithContext(ctx).
+			Model(&key).
+			UpdateColumn("last_used_at", now).Error; err != nil {
+			logger.FromContext(ctx).ErrLogger.ErrorContext(ctx, err.Error(), "entity", APIKey)
+		}
+	}
+
+	creator, err := s.permissions.Resolve(ctx, key.CreatedBy)
+	if err != nil {
+		var appErr *appErrors.AppError
+		if errors.As(err, &appErr) && appErr.Code == codes.INVALID_TOKEN {
+			return nil, appErrors.NewAuth(codes.INVALID_API_KEY, errors.New("api key creator no longer exists"))
+		}
+		return nil, err
+	}
+	if creator.Suspended {
+		return nil, appErrors.NewAuth(codes.INVALID_API_KEY, errors.New("api key creator is suspended"))
+	}
+	key.Permissions = limitPermissions(key.Permissions, creator.Permissions)
+
+	return &key, nil
+}
+
+// limitPermissions returns the part of perms that held also grants. A wildcard in perms
+// that held only partly covers is narrowed to the held permissions it matches.
+func limitPermissions(perms, held []string) []string {
+	expanded := utils.ExpandPermissions(held)
+
+	limited := make([]string, 0, len(perms))
+	for _, perm := range perms {
+		if utils.HasPermission(held, constants.Permission(perm)) {
+			limited = append(limited, perm)
+			continue
+		}
+
+		if !strings.Contains(perm, "*") {
+			continue
+		}
+		for _, name := range expanded {
+			if utils.MatchPermission(perm, constants.Permission(name)) {
+				limited = append(limited, name)
+			}
+		}
+	}
+
+	return uniqueStrings(limited)
+}
+
+// ipAllowed reports whether ip matches one of the addresses or CIDR ranges in allowed.
+// An empty allowlist allows every IP.
+func ipAllowed(ip string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, entry := range allowed {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(parsed) {
+				return true
+			}
+			continue
+		}
+
+		if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			unique = append(unique, v)
+		}
+	}
+
+	return unique
+}
+
+// normalizePermissions returns the unique permissions under their current names.
+func normalizePermissions(perms []string) []string {
+	normalized := make([]string, len(perms))
+	for i, perm := range perms {
+		normalized[i] = utils.NormalizePermission(perm)
+	}
+
+	return uniqueStrings(normalized)
+}
+
+// coversKnownPermission reports whether perm is a known permission, or a wildcard
+// matching at least one of them.
+func coversKnownPermission(perm string, known []string) bool {
+	for _, name := range known {
+		if name == perm || (strings.Contains(perm, "*") && utils.MatchPermission(perm, constants.Permission(name))) {
+			return true
+		}
+	}
+
+	return false
+}
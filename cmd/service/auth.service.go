@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/revocation"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+const Auth = entities.AUTHORIZATION
+
+var errRefreshTokenConsumed = errors.New("refresh token already consumed")
+
+// sessionTouchInterval limits how often last_seen_at is written for an active session.
+const sessionTouchInterval = 5 * time.Minute
+
+type AuthService struct {
+	tokens      *models.RefreshTokenModel
+	revoked     revocation.Store
+	sessions    *models.SessionModel
+	permissions *PermissionService
+
+	// lastSeen remembers when each session was last written so requests do not all hit the database
+	lastSeen sync.Map
+}
+
+// IssueTokens signs a new access token and starts a new session, and with it a new
+// refresh token family, for the user. The device is taken from the request context.
+func (s *AuthService) IssueTokens(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if err := checkSuspended(user); err != nil {
+		return nil, err
+	}
+
+	familyID := cuid.New()
+	userAgent, _ := ctx.Value(constants.USER_AGENT_KEY).(string)
+
+	var refreshToken string
+	err := s.tokens.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Create(&models.Session{
+			ID:         familyID,
+			UserID:     user.ID,
+			Device:     describeDevice(userAgent),
+			UserAgent:  truncate(userAgent, 512),
+			IP:         clientIP(ctx),
+			CreatedAt:  now,
+			LastSeenAt: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		token, err := s.createRefreshToken(tx, user.ID, familyID)
+		refreshToken = token
+		return err
+	})
+	if err != nil {
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	token, err := utils.GenerateJWT(user.ID, familyID, user.TokenVersion, user.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:                  token,
+		RefreshToken:           refreshToken,
+		User:                   *user,
+		PasswordChangeRequired: passwordChangeRequired(user),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair.
+// Each refresh token can be used once; presenting a token that was already
+// rotated is treated as theft and revokes every token in its family.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	var existing models.RefreshToken
+	if err := s.tokens.DB.WithContext(ctx).
+		Where("token_hash = ?", utils.HashToken(refreshToken)).
+		First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.NewAuth(codes.INVALID_REFRESH_TOKEN, err)
+		}
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	if existing.RevokedAt != nil {
+		return nil, appErrors.NewAuth(codes.INVALID_REFRESH_TOKEN, errors.New("refresh token revoked"))
+	}
+
+	if existing.UsedAt != nil {
+		log.ErrLogger.ErrorContext(ctx, "refresh token reuse detected", "family_id", existing.FamilyID, "user_id", existing.UserID)
+		if err := s.revokeFamily(ctx, existing.FamilyID); err != nil {
+			return nil, appErrors.FromDb(Auth, err)
+		}
+		return nil, appErrors.NewAuth(codes.REFRESH_TOKEN_REUSED, errors.New("refresh token reused"))
+	}
+
+	if time.Now().After(existing.ExpiresAt) {
+		return nil, appErrors.NewAuth(codes.EXPIRED_REFRESH_TOKEN, errors.New("refresh token expired"))
+	}
+
+	var user models.User
+	var rotated string
+	err := s.tokens.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only one request may consume a token, concurrent rotations lose the race here
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", existing.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRefreshTokenConsumed
+		}
+
+		if err := tx.Where("id = ?", existing.UserID).
+			Preload("Roles.Permissions").
+			Scopes(preloadActiveRoles(existing.UserID, time.Now())).
+			First(&user).Error; err != nil {
+			return err
+		}
+
+		if err := checkSuspended(&user); err != nil {
+			return err
+		}
+
+		token, err := s.createRefreshToken(tx, existing.UserID, existing.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Session{}).
+			Where("id = ?", existing.FamilyID).
+			Updates(map[string]interface{}{"last_seen_at": time.Now(), "ip": clientIP(ctx)}).Error; err != nil {
+			return err
+		}
+
+		rotated = token
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, errRefreshTokenConsumed) {
+			if revokeErr := s.revokeFamily(ctx, existing.FamilyID); revokeErr != nil {
+				return nil, appErrors.FromDb(Auth, revokeErr)
+			}
+			return nil, appErrors.NewAuth(codes.REFRESH_TOKEN_REUSED, err)
+		}
+		if _, ok := err.(*appErrors.AppError); ok {
+			return nil, err
+		}
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth)
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	token, err := utils.GenerateJWT(user.ID, existing.FamilyID, user.TokenVersion, user.Roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:                  token,
+		RefreshToken:           rotated,
+		User:                   user,
+		PasswordChangeRequired: passwordChangeRequired(&user),
+	}, nil
+}
+
+// Authenticate verifies an access token and rejects it when it has been revoked,
+// either on its own or through a "logout everywhere" of its user. The permissions
+// of the returned claims are the current permissions of the user.
+func (s *AuthService) Authenticate(ctx context.Context, tokenString string) (*utils.Claims, error) {
+	claims, err := utils.VerifyJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenKey := revocation.TokenKey(claims.ID)
+	sessionKey := revocation.SessionKey(claims.SessionID)
+	userKey := revocation.UserKey(claims.UserID)
+
+	revoked, err := s.revoked.Lookup(ctx, tokenKey, sessionKey, userKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := revoked[tokenKey]; ok {
+		return nil, appErrors.NewAuth(codes.TOKEN_REVOKED, errors.New("token revoked"))
+	}
+
+	if _, ok := revoked[sessionKey]; ok && claims.SessionID != "" {
+		return nil, appErrors.NewAuth(codes.TOKEN_REVOKED, errors.New("session revoked"))
+	}
+
+	// iat only has second precision, so a token issued in the same second as the revocation
+	// is kept: it may be the one signed right after it, and older ones carry an old token version
+	if revokedAt, ok := revoked[userKey]; ok && claims.IssuedAt != nil && claims.IssuedAt.Before(revokedAt.Truncate(time.Second)) {
+		return nil, appErrors.NewAuth(codes.TOKEN_REVOKED, errors.New("all tokens of user revoked"))
+	}
+
+	access, err := s.permissions.Resolve(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if access.Suspended {
+		return nil, appErrors.NewAuth(codes.ACCOUNT_SUSPENDED, errors.New("account suspended"))
+	}
+
+	if claims.Version != access.TokenVersion {
+		return nil, appErrors.NewAuth(codes.TOKEN_REVOKED, errors.New("token version outdated"))
+	}
+	claims.Permissions = access.Permissions
+
+	// An impersonation ends as soon as the impersonator loses the right to impersonate
+	if claims.Actor != nil {
+		actor, err := s.permissions.Resolve(ctx, claims.Actor.Subject)
+		if err != nil {
+			return nil, err
+		}
+
+		if !utils.HasPermission(actor.Permissions, constants.ImpersonateUser) {
+			return nil, appErrors.NewAuth(codes.TOKEN_REVOKED, errors.New("impersonator no longer allowed to impersonate"))
+		}
+		claims.Permissions = capPermissions(access.Permissions, actor.Permissions)
+	}
+
+	s.touchSession(ctx, claims.SessionID)
+
+	return claims, nil
+}
+
+// Logout revokes the access token and the refresh token family it was issued with.
+func (s *AuthService) Logout(ctx context.Context, claims *utils.Claims) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	expiresAt := time.Now().Add(utils.AccessTokenTTL())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	if err := s.revoked.Revoke(ctx, revocation.TokenKey(claims.ID), expiresAt); err != nil {
+		return appErrors.FromDb(Auth, err)
+	}
+
+	if claims.SessionID != "" {
+		if _, err := s.RevokeSession(ctx, claims.SessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RevokeSession signs a session out: its refresh tokens stop working and its access
+// tokens are rejected right away. It reports false when the session was already revoked.
+func (s *AuthService) RevokeSession(ctx context.Context, sessionID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	result := s.sessions.DB.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, appErrors.FromDb(Auth, result.Error)
+	}
+
+	if err := s.revokeFamily(ctx, sessionID); err != nil {
+		return false, appErrors.FromDb(Auth, err)
+	}
+
+	if err := s.revoked.Revoke(ctx, revocation.SessionKey(sessionID), time.Now().Add(utils.AccessTokenTTL())); err != nil {
+		return false, appErrors.FromDb(Auth, err)
+	}
+
+	s.lastSeen.Delete(sessionID)
+	return result.RowsAffected > 0, nil
+}
+
+// LogoutAll revokes every access and refresh token of the user on their own request.
+// An impersonator cannot sign the impersonated user out everywhere.
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	return s.revokeAll(ctx, userID)
+}
+
+// revokeAll revokes every access and refresh token of the user.
+func (s *AuthService) revokeAll(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	// Access tokens issued before now are all expired once a full TTL has passed
+	if err := s.revoked.Revoke(ctx, revocation.UserKey(userID), time.Now().Add(utils.AccessTokenTTL())); err != nil {
+		return appErrors.FromDb(Auth, err)
+	}
+
+	if err := s.tokens.DB.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return appErrors.FromDb(Auth, err)
+	}
+
+	if err := s.sessions.DB.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return appErrors.FromDb(Auth, err)
+	}
+
+	// Tokens carrying an older version are rejected right away on this instance, and on other
+	// instances once their cached access expires after PERMISSION_CACHE_TTL, even without a
+	// shared revocation store
+	if err := s.tokens.DB.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return appErrors.FromDb(Auth, err)
+	}
+	s.permissions.Invalidate(userID)
+
+	return nil
+}
+
+// touchSession records that a session was used, at most once per sessionTouchInterval.
+func (s *AuthService) touchSession(ctx context.Context, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+
+	now := time.Now()
+	if last, ok := s.lastSeen.Load(sessionID); ok && now.Sub(last.(time.Time)) < sessionTouchInterval {
+		return
+	}
+	s.lastSeen.Store(sessionID, now)
+
+	if err := s.sessions.DB.WithContext(ctx).
+		Model(&models.Session{}).
+		Where("id = ?", sessionID).
+		UpdateColumn("last_seen_at", now).Error; err != nil {
+		logger.FromContext(ctx).ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth)
+	}
+}
+
+// StartLastSeenPruner forgets sessions not touched for a sessionTouchInterval every interval
+// until ctx is done, so sessions that stopped making requests do not stay in memory.
+func (s *AuthService) StartLastSeenPruner(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.pruneLastSeen(now)
+			}
+		}
+	}()
+}
+
+// pruneLastSeen drops the sessions whose next touch would write to the database anyway.
+func (s *AuthService) pruneLastSeen(now time.Time) {
+	s.lastSeen.Range(func(key, value any) bool {
+		if now.Sub(value.(time.Time)) >= sessionTouchInterval {
+			s.lastSeen.Delete(key)
+		}
+		return true
+	})
+}
+
+func (s *AuthService) createRefreshToken(db *gorm.DB, userID, familyID string) (string, error) {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	token := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+
+	if err := db.Create(&token).Error; err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+func (s *AuthService) revokeFamily(ctx context.Context, familyID string) error {
+	return s.tokens.DB.WithContext(ctx).
+		Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// passwordChangeRequired reports whether the password of user is temporary or older than the password policy allows.
+func passwordChangeRequired(user *models.User) bool {
+	if user.MustChangePassword {
+		return true
+	}
+
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+
+	return utils.PasswordPolicyFromEnv().Expired(changedAt)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+)
+
+type ImpersonationService struct {
+	impersonations *models.ImpersonationModel
+	permissions    *PermissionService
+}
+
+// Start signs the caller in as targetID. The token it returns expires after
+// IMPERSONATION_TTL, cannot be refreshed and never grants a permission the caller lacks.
+func (s *ImpersonationService) Start(ctx context.Context, actor *utils.Claims, targetID, reason string) (*models.ImpersonationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if actor.Actor != nil {
+		return nil, appErrors.NewAuth(codes.CANNOT_IMPERSONATE, errors.New("impersonation tokens cannot start another impersonation"))
+	}
+	if actor.UserID == targetID {
+		return nil, appErrors.NewAuth(codes.CANNOT_IMPERSONATE, errors.New("cannot impersonate yourself"))
+	}
+
+	var target models.User
+	if err := s.impersonations.DB.WithContext(ctx).
+		Where("id = ?", targetID).
+		Scopes(preloadActiveRoles(targetID, time.Now())).
+		First(&target).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	access, err := s.permissions.Resolve(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := env.GetDurationEnv("IMPERSONATION_TTL", 15*time.Minute)
+	userAgent, _ := ctx.Value(constants.USER_AGENT_KEY).(string)
+
+	entry := models.ImpersonationLog{
+		ActorID:     actor.UserID,
+		TargetID:    target.ID,
+		Reason:      reason,
+		Permissions: capPermissions(access.Permissions, actor.Permissions),
+		IP:          clientIP(ctx),
+		UserAgent:   truncate(userAgent, 512),
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+
+	if err := s.impersonations.DB.WithContext(ctx).Create(&entry).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth)
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	token, err := utils.GenerateImpersonationJWT(target.ID, actor.UserID, entry.ID, access.TokenVersion, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	log.InfoLogger.InfoContext(ctx, "Impersonation started",
+		"impersonationID", entry.ID,
+		"actorID", actor.UserID,
+		"targetID", target.ID,
+		"reason", reason,
+	)
+
+	return &models.ImpersonationResponse{
+		Token:       token,
+		ExpiresAt:   entry.ExpiresAt,
+		Permissions: entry.Permissions,
+		User:        target,
+	}, nil
+}
+
+// capPermissions returns the permissions of target that actor holds as well, so an
+// impersonation never exceeds the impersonator. Impersonating is never passed on.
+func capPermissions(target, actor []string) []string {
+	capped := make([]string, 0, len(target))
+	for _, perm := range target {
+		if perm == string(constants.ImpersonateUser) {
+			continue
+		}
+		if utils.HasPermission(actor, constants.Permission(perm)) {
+			capped = append(capped, perm)
+		}
+	}
+
+	return capped
+}
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// dummyPasswordHash is compared against when the email is unknown so that
+// both failure cases take as long as a real password check.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := utils.HashPassword("not-a-real-password")
+	return hash
+})
+
+// LoginThrottledError is wrapped in the TOO_MANY_LOGIN_ATTEMPTS error and tells the
+// client how long to wait before trying again.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("login throttled, retry after %s", e.RetryAfter)
+}
+
+// RetryAfterSeconds rounds the wait up to whole seconds for the Retry-After header.
+func (e *LoginThrottledError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+type LockoutService struct {
+	users    *models.UserModel
+	attempts *attemptTracker
+}
+
+// Login checks the credentials of a sign in attempt. Failed attempts are
+// counted per client IP and per account: every failure makes the caller wait
+// longer before the next try, and too many failures lock the account or block
+// the IP for a while. Unknown emails and wrong passwords fail the same way.
+func (s *LockoutService) Login(ctx context.Context, email, password string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+	now := time.Now()
+
+	ipKey := "ip:" + clientIP(ctx)
+	if wait := s.attempts.wait(ipKey, now); wait > 0 {
+		return nil, throttled(wait)
+	}
+
+	var user models.User
+	err := s.users.DB.WithContext(ctx).
+		Where("email = ?", email).
+		Preload("Roles.Permissions").
+		Scopes(preloadActiveRoles(s.users.DB.Model(&models.User{}).Select("id").Where("email = ?", email), now)).
+		First(&user).Error
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+			return nil, appErrors.FromDb(User, err)
+		}
+
+		// Unknown emails are throttled in memory exactly like real accounts
+		// so the lockout itself does not reveal which emails are registered
+		emailKey := "email:" + strings.ToLower(email)
+		if wait := s.attempts.wait(emailKey, now); wait > 0 {
+			return nil, throttled(wait)
+		}
+
+		_ = utils.VerifyWithHashed(password, dummyPasswordHash())
+		s.attempts.fail(ipKey, now, ipMaxAttempts())
+		s.attempts.fail(emailKey, now, accountMaxAttempts())
+		return nil, appErrors.NewAuth(codes.INVALID_EMAIL_OR_PASSWORD, errors.New("unknown email"))
+	}
+
+	if err := s.throttle(&user, now); err != nil {
+		return nil, err
+	}
+
+	if err := utils.VerifyWithHashed(password, user.Password); err != nil {
+		s.attempts.fail(ipKey, now, ipMaxAttempts())
+		if err := s.recordFailure(ctx, &user, now); err != nil {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+			return nil, appErrors.FromDb(User, err)
+		}
+		return nil, appErrors.NewAuth(codes.INVALID_EMAIL_OR_PASSWORD, errors.New("password mismatch"))
+	}
+
+	// Checked after the password so a suspension does not reveal that the email is registered
+	if err := checkSuspended(&user); err != nil {
+		return nil, err
+	}
+
+	if err := s.resetFailures(ctx, &user); err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	// The plain password is only available here, so old hashes are upgraded on login
+	if utils.NeedsRehash(user.Password) {
+		s.rehash(ctx, &user, password)
+	}
+
+	return &user, nil
+}
+
+// StartAttemptSweeper purges expired in memory login attempts every interval until ctx is done.
+func (s *LockoutService) StartAttemptSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.attempts.purge(now)
+			}
+		}
+	}()
+}
+
+// rehash replaces the stored hash of user with one made with the current scheme.
+// Failures are logged only since the login itself succeeded.
+func (s *LockoutService) rehash(ctx context.Context, user *models.User, password string) {
+	log := logger.FromContext(ctx)
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return
+	}
+
+	// Matching on the old hash keeps a concurrent password change from being overwritten
+	if err := s.users.DB.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		UpdateColumn("password", hashed).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return
+	}
+
+	user.Password = hashed
+	log.InfoLogger.InfoContext(ctx, "Password hash upgraded", "userID", user.ID)
+}
+
+// Unlock clears the lockout and failed attempt counter of a user.
+func (s *LockoutService) Unlock(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if err := requireCoversUser(ctx, s.users.DB.WithContext(ctx), userID); err != nil {
+		return err
+	}
+
+	result := s.users.DB.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"failed_login_attempts": 0, "last_failed_login_at": nil, "locked_until": nil})
+	if result.Error != nil {
+		log.ErrLogger.ErrorContext(ctx, result.Error.Error(), "entity", User)
+		return appErrors.FromDb(User, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.FromDb(User, gorm.ErrRecordNotFound)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User account unlocked", "userID", userID)
+	return nil
+}
+
+// throttle returns the error for a user whose account is locked or who has to wait
+// after recent failures, or nil when they may try again.
+func (s *LockoutService) throttle(user *models.User, now time.Time) error {
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return throttled(user.LockedUntil.Sub(now))
+	}
+
+	if user.LastFailedLoginAt != nil {
+		if next := user.LastFailedLoginAt.Add(loginDelay(user.FailedLoginAttempts)); now.Before(next) {
+			return throttled(next.Sub(now))
+		}
+	}
+
+	return nil
+}
+
+// resetFailures clears the failed attempts of user after a successful credential check.
+func (s *LockoutService) resetFailures(ctx context.Context, user *models.User) error {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return nil
+	}
+
+	return s.users.DB.WithContext(ctx).
+		Model(user).
+		Updates(map[string]interface{}{"failed_login_attempts": 0, "last_failed_login_at": nil, "locked_until": nil}).Error
+}
+
+// recordFailure counts a failed credential check of user toward the account lockout.
+func (s *LockoutService) recordFailure(ctx context.Context, user *models.User, now time.Time) error {
+	attempts := user.FailedLoginAttempts + 1
+	updates := map[string]interface{}{
+		"failed_login_attempts": gorm.Expr("failed_login_attempts + 1"),
+		"last_failed_login_at":  now,
+	}
+
+	// Failures older than the attempt window no longer count
+	if user.LastFailedLoginAt != nil && now.Sub(*user.LastFailedLoginAt) > attemptWindow() {
+		attempts = 1
+		updates["failed_login_attempts"] = 1
+	}
+
+	if attempts >= accountMaxAttempts() {
+		updates["failed_login_attempts"] = 0
+		updates["locked_until"] = now.Add(env.GetDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute))
+		logger.FromContext(ctx).ErrLogger.ErrorContext(ctx, "user account locked after failed logins", "userID", user.ID, "attempts", attempts)
+	}
+
+	return s.users.DB.WithContext(ctx).Model(user).Updates(updates).Error
+}
+
+func throttled(wait time.Duration) error {
+	return appErrors.NewAuth(codes.TOO_MANY_LOGIN_ATTEMPTS, &LoginThrottledError{RetryAfter: wait})
+}
+
+// clientIP returns the request IP resolved by the request middleware, or "unknown" outside
+// a request.
+func clientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(constants.CLIENT_IP_KEY).(string); ok {
+		return ip
+	}
+	return "unknown"
+}
+
+func accountMaxAttempts() int {
+	return env.GetIntEnv("LOGIN_MAX_ATTEMPTS", 5)
+}
+
+func ipMaxAttempts() int {
+	return env.GetIntEnv("LOGIN_IP_MAX_ATTEMPTS", 20)
+}
+
+// loginDelay is how long to wait after the given number of consecutive failures.
+// The first couple of failures are free, after that the delay doubles each time.
+func loginDelay(failures int) time.Duration {
+	if failures < 3 {
+		return 0
+	}
+
+	base := env.GetDurationEnv("LOGIN_DELAY_BASE", time.Second)
+	limit := env.GetDurationEnv("LOGIN_DELAY_MAX", 30*time.Second)
+
+	delay := base << (failures - 3)
+	if delay <= 0 || delay > limit {
+		return limit
+	}
+	return delay
+}
+
+type attempt struct {
+	failures    int
+	lastFailure time.Time
+	blockedTill time.Time
+}
+
+// attemptTracker counts failed logins of keys that have no database row,
+// client IPs and unknown emails, in process memory.
+type attemptTracker struct {
+	mu      sync.Mutex
+	entries map[string]*attempt
+}
+
+func newAttemptTracker() *attemptTracker {
+	return &attemptTracker{entries: make(map[string]*attempt)}
+}
+
+// wait returns how long key has to wait before its next attempt.
+func (t *attemptTracker) wait(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok {
+		return 0
+	}
+
+	if now.Before(entry.blockedTill) {
+		return entry.blockedTill.Sub(now)
+	}
+
+	if now.Sub(entry.lastFailure) > attemptWindow() {
+		delete(t.entries, key)
+		return 0
+	}
+
+	if next := entry.lastFailure.Add(loginDelay(entry.failures)); now.Before(next) {
+		return next.Sub(now)
+	}
+
+	return 0
+}
+
+// fail counts a failed attempt of key and reports whether it reached max, which blocks the key.
+func (t *attemptTracker) fail(key string, now time.Time, max int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok && len(t.entries) >= maxTrackedAttempts() {
+		t.sweep(now)
+		if len(t.entries) >= maxTrackedAttempts() {
+			t.evictOldest()
+		}
+	}
+
+	if !ok || now.Sub(entry.lastFailure) > attemptWindow() {
+		entry = &attempt{}
+		t.entries[key] = entry
+	}
+
+	entry.failures++
+	entry.lastFailure = now
+
+	if entry.failures >= max {
+		entry.failures = 0
+		entry.blockedTill = now.Add(env.GetDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute))
+		return true
+	}
+
+	return false
+}
+
+// purge drops the entries that no longer throttle anything.
+func (t *attemptTracker) purge(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+}
+
+// evictOldest makes room for a new key by dropping the entry that failed longest ago.
+func (t *attemptTracker) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range t.entries {
+		if oldestKey == "" || entry.lastFailure.Before(oldest) {
+			oldestKey, oldest = key, entry.lastFailure
+		}
+	}
+	delete(t.entries, oldestKey)
+}
+
+// sweep drops entries that are neither blocked nor inside the attempt window.
+func (t *attemptTracker) sweep(now time.Time) {
+	for key, entry := range t.entries {
+		if now.After(entry.blockedTill) && now.Sub(entry.lastFailure) > attemptWindow() {
+			delete(t.entries, key)
+		}
+	}
+}
+
+// maxTrackedAttempts caps the in memory entries so a flood of distinct keys cannot
+// exhaust memory.
+func maxTrackedAttempts() int {
+	return env.GetIntEnv("LOGIN_TRACKER_MAX_ENTRIES", 10000)
+}
+
+func attemptWindow() time.Duration {
+	return env.GetDurationEnv("LOGIN_ATTEMPT_WINDOW", 15*time.Minute)
+}
@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/revocation"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+// mfaPrivilegedPermissions always require a second factor, whatever the role policy says.
+var mfaPrivilegedPermissions = []constants.Permission{constants.FullAccess, constants.ManageRoles}
+
+type MFAService struct {
+	mfa      *models.MFAModel
+	auth     *AuthService
+	lockout  *LockoutService
+	attempts *attemptTracker
+}
+
+// Mandatory reports whether the roles of user, or the roles they inherit from, force
+// a second factor. user.Roles must be loaded with their permissions.
+func (s *MFAService) Mandatory(ctx context.Context, user *models.User) (bool, error) {
+	ids := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		ids = append(ids, role.ID)
+	}
+
+	ancestors, err := roleAncestors(s.mfa.DB.WithContext(ctx), ids)
+	if err != nil {
+		return false, appErrors.FromDb(Role, err)
+	}
+
+	for _, role := range append(user.Roles, ancestors...) {
+		if role.RequireMFA {
+			return true, nil
+		}
+
+		for _, perm := range role.Permissions {
+			for _, privileged := range mfaPrivilegedPermissions {
+				if utils.HasPermission([]string{perm.Name}, privileged) {
+					return true, nil
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Challenge returns the second login step for user, or nil when a password is enough.
+func (s *MFAService) Challenge(ctx context.Context, user *models.User) (*models.MFAChallengeResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	enrolled, err := s.confirmed(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	mandatory, err := s.Mandatory(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled == nil && !mandatory {
+		return nil, nil
+	}
+
+	token, err := utils.GenerateActionToken(utils.PurposeMFA, user.ID, user.Email, env.GetDurationEnv("MFA_TOKEN_TTL", 5*time.Minute))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAChallengeResponse{
+		MFARequired:        true,
+		EnrollmentRequired: enrolled == nil,
+		MFAToken:           token,
+	}, nil
+}
+
+// Enroll creates a new unconfirmed TOTP secret for the user, replacing any previous unconfirmed one.
+func (s *MFAService) Enroll(ctx context.Context, userID string) (*models.MFAEnrollResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.mfa.DB.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	enrolled, err := s.confirmed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled != nil {
+		return nil, appErrors.NewAuth(codes.MFA_ALREADY_ENABLED, errors.New("mfa already enabled"))
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mfa.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.UserMFA{UserID: userID, Secret: secret}).Error
+	})
+
+	if err != nil {
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	return &models.MFAEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(env.GetStringEnv("MFA_ISSUER", "Bag Shop"), user.Email, secret),
+	}, nil
+}
+
+// Confirm activates a pending enrollment with a first valid code and returns fresh recovery codes.
+func (s *MFAService) Confirm(ctx context.Context, userID, code string) (*models.RecoveryCodesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var pending models.UserMFA
+	if err := s.mfa.DB.WithContext(ctx).Where("user_id = ?", userID).First(&pending).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.NewAuth(codes.MFA_NOT_ENABLED, err)
+		}
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	if pending.ConfirmedAt != nil {
+		return nil, appErrors.NewAuth(codes.MFA_ALREADY_ENABLED, errors.New("mfa already enabled"))
+	}
+
+	if err := s.checkCode(ctx, &pending, code); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	err := s.mfa.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&pending).Update("confirmed_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		generated, err := s.replaceRecoveryCodes(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		recoveryCodes = generated
+		return nil
+	})
+
+	if err != nil {
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	logger.FromContext(ctx).InfoLogger.InfoContext(ctx, "MFA enabled", "userID", userID)
+	return &models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// Verify completes a login started with a password by checking a TOTP or recovery code.
+// Wrong codes count toward the account lockout like wrong passwords, and a pending
+// token is revoked after MFA_TOKEN_MAX_ATTEMPTS wrong codes.
+func (s *MFAService) Verify(ctx context.Context, req *models.MFAVerifyRequest) (*models.AuthResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+	now := time.Now()
+
+	claims, err := utils.VerifyActionToken(utils.PurposeMFA, req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenKey := revocation.TokenKey(claims.ID)
+	revoked, err := s.auth.revoked.Lookup(ctx, tokenKey)
+	if err != nil {
+		return nil, appErrors.FromDb(Auth, err)
+	}
+	if _, ok := revoked[tokenKey]; ok {
+		return nil, appErrors.NewAuth(codes.TOKEN_REVOKED, errors.New("mfa token revoked"))
+	}
+
+	var user models.User
+	if err := s.mfa.DB.WithContext(ctx).
+		Where("id = ?", claims.Subject).
+		Preload("Roles.Permissions").
+		Scopes(preloadActiveRoles(claims.Subject, now)).
+		First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	if err := s.lockout.throttle(&user, now); err != nil {
+		return nil, err
+	}
+
+	enrolled, err := s.confirmed(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled == nil {
+		return nil, appErrors.NewAuth(codes.MFA_NOT_ENABLED, errors.New("mfa not enabled"))
+	}
+
+	if req.RecoveryCode != "" {
+		err = s.useRecoveryCode(ctx, claims.Subject, req.RecoveryCode)
+	} else {
+		err = s.checkCode(ctx, enrolled, req.Code)
+	}
+	if err != nil {
+		if isInvalidMFACode(err) {
+			s.recordFailure(ctx, &user, claims, now)
+		}
+		return nil, err
+	}
+
+	if err := s.lockout.resetFailures(ctx, &user); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+	}
+
+	return s.auth.IssueTokens(ctx, &user)
+}
+
+// recordFailure counts a wrong code against the account of user and against the pending
+// token when there is one, and revokes the token once it ran out of attempts. Failures are
+// logged only so the client still gets the invalid code error.
+func (s *MFAService) recordFailure(ctx context.Context, user *models.User, claims *utils.ActionClaims, now time.Time) {
+	log := logger.FromContext(ctx)
+
+	if err := s.lockout.recordFailure(ctx, user, now); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+	}
+
+	if claims == nil || !s.attempts.fail("mfa:"+claims.ID, now, env.GetIntEnv("MFA_TOKEN_MAX_ATTEMPTS", 5)) {
+		return
+	}
+
+	expiresAt := now.Add(env.GetDurationEnv("MFA_TOKEN_TTL", 5*time.Minute))
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if err := s.auth.revoked.Revoke(ctx, revocation.TokenKey(claims.ID), expiresAt); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth)
+		return
+	}
+
+	log.InfoLogger.InfoContext(ctx, "MFA token revoked after failed codes", "userID", user.ID)
+}
+
+// checkUserCode checks a TOTP code of a signed in user. Like at login, a locked account is
+// refused and wrong codes count toward the account lockout.
+func (s *MFAService) checkUserCode(ctx context.Context, user *models.User, enrolled *models.UserMFA, code string) error {
+	now := time.Now()
+
+	if err := s.lockout.throttle(user, now); err != nil {
+		return err
+	}
+
+	if err := s.checkCode(ctx, enrolled, code); err != nil {
+		if isInvalidMFACode(err) {
+			s.recordFailure(ctx, user, nil, now)
+		}
+		return err
+	}
+
+	if err := s.lockout.resetFailures(ctx, user); err != nil {
+		logger.FromContext(ctx).ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+	}
+	return nil
+}
+
+func isInvalidMFACode(err error) bool {
+	var appErr *appErrors.AppError
+	return errors.As(err, &appErr) && appErr.Code == codes.INVALID_MFA_CODE
+}
+
+// Disable removes the second factor of a user after checking a current code.
+// Users whose roles make MFA mandatory cannot disable it.
+func (s *MFAService) Disable(ctx context.Context, userID, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.mfa.DB.WithContext(ctx).
+		Where("id = ?", userID).
+		Preload("Roles.Permissions").
+		Scopes(preloadActiveRoles(userID, time.Now())).
+		First(&user).Error; err != nil {
+		return appErrors.FromDb(User, err)
+	}
+
+	mandatory, err := s.Mandatory(ctx, &user)
+	if err != nil {
+		return err
+	}
+	if mandatory {
+		return appErrors.NewAuth(codes.MFA_REQUIRED_BY_ROLE, errors.New("mfa required by role"))
+	}
+
+	enrolled, err := s.confirmed(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if enrolled == nil {
+		return appErrors.NewAuth(codes.MFA_NOT_ENABLED, errors.New("mfa not enabled"))
+	}
+
+	if err := s.checkUserCode(ctx, &user, enrolled, code); err != nil {
+		return err
+	}
+
+	err = s.mfa.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&models.UserMFA{}).Error
+	})
+
+	if err != nil {
+		return appErrors.FromDb(Auth, err)
+	}
+
+	logger.FromContext(ctx).InfoLogger.InfoContext(ctx, "MFA disabled", "userID", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates the remaining recovery codes and returns a new set.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*models.RecoveryCodesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.mfa.DB.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	enrolled, err := s.confirmed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrolled == nil {
+		return nil, appErrors.NewAuth(codes.MFA_NOT_ENABLED, errors.New("mfa not enabled"))
+	}
+
+	if err := s.checkUserCode(ctx, &user, enrolled, code); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	err = s.mfa.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		generated, err := s.replaceRecoveryCodes(tx, userID)
+		recoveryCodes = generated
+		return err
+	})
+
+	if err != nil {
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// confirmed returns the confirmed MFA settings of a user, or nil if MFA is not enabled.
+func (s *MFAService) confirmed(ctx context.Context, userID string) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	if err := s.mfa.DB.WithContext(ctx).
+		Where("user_id = ? AND confirmed_at IS NOT NULL", userID).
+		First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	return &mfa, nil
+}
+
+// checkCode validates a TOTP code and records its time step so the same code cannot be replayed.
+func (s *MFAService) checkCode(ctx context.Context, mfa *models.UserMFA, code string) error {
+	step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now())
+	if !ok || step <= mfa.LastUsedStep {
+		return appErrors.NewAuth(codes.INVALID_MFA_CODE, errors.New("invalid totp code"))
+	}
+
+	result := s.mfa.DB.WithContext(ctx).
+		Model(&models.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", mfa.UserID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return appErrors.FromDb(Auth, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.NewAuth(codes.INVALID_MFA_CODE, errors.New("totp code already used"))
+	}
+
+	mfa.LastUsedStep = step
+	return nil
+}
+
+func (s *MFAService) useRecoveryCode(ctx context.Context, userID, code string) error {
+	result := s.mfa.DB.WithContext(ctx).
+		Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return appErrors.FromDb(Auth, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.NewAuth(codes.INVALID_MFA_CODE, errors.New("invalid recovery code"))
+	}
+
+	logger.FromContext(ctx).InfoLogger.InfoContext(ctx, "MFA recovery code used", "userID", userID)
+	return nil
+}
+
+func (s *MFAService) replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	generated, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.RecoveryCode, 0, len(generated))
+	for _, code := range generated {
+		rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	return generated, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed loosely.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	return utils.HashToken(normalized)
+}
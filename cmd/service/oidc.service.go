@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/oidc"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+var errOAuthStateConsumed = errors.New("oauth state already used")
+
+type OIDCService struct {
+	oidc      *models.OIDCModel
+	providers map[string]*oidc.Provider
+}
+
+// Start begins a social login with provider and returns the URL to send the user to.
+// The state, nonce and PKCE verifier of the attempt are kept until the callback. The
+// returned binding must be kept by the browser that started the login and handed back
+// to Callback, so a state cannot be replayed from another browser.
+func (s *OIDCService) Start(ctx context.Context, provider string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", appErrors.NewAuth(codes.UNKNOWN_OIDC_PROVIDER, fmt.Errorf("oidc provider %q not configured", provider))
+	}
+
+	state, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth, "provider", provider)
+		return "", "", appErrors.NewAuth(codes.OIDC_LOGIN_FAILED, err)
+	}
+
+	// Abandoned logins are cleared here instead of by a background job
+	if err := s.oidc.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{}).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth)
+	}
+
+	if err := s.oidc.DB.WithContext(ctx).Create(&models.OAuthState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(env.GetDurationEnv("OIDC_STATE_TTL", 10*time.Minute)),
+	}).Error; err != nil {
+		return "", "", appErrors.FromDb(Auth, err)
+	}
+
+	return authURL, utils.HashToken(state), nil
+}
+
+// Callback completes a social login. It checks the state against the binding returned by
+// Start, exchanges the code, verifies the ID token and returns the linked user. Unknown
+// identities are linked to the account with the same email, or get a new account, but only
+// when the provider verified the email.
+func (s *OIDCService) Callback(ctx context.Context, provider, state, binding, code string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, appErrors.NewAuth(codes.UNKNOWN_OIDC_PROVIDER, fmt.Errorf("oidc provider %q not configured", provider))
+	}
+
+	// A callback opened in another browser, e.g. from a link planted by an attacker,
+	// must not sign that browser in
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(state)), []byte(binding)) != 1 {
+		return nil, appErrors.NewAuth(codes.INVALID_OAUTH_STATE, errors.New("oauth state not bound to this browser"))
+	}
+
+	pending, err := s.consumeState(ctx, provider, state)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := p.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth, "provider", provider)
+		return nil, appErrors.NewAuth(codes.OIDC_LOGIN_FAILED, err)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, tokens.IDToken, pending.Nonce)
+	if err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth, "provider", provider)
+		return nil, appErrors.NewAuth(codes.OIDC_LOGIN_FAILED, err)
+	}
+
+	var user models.User
+	err = s.oidc.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userID, err := s.resolveUser(tx, provider, claims)
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", userID).
+			Preload("Roles.Permissions").
+			Scopes(preloadActiveRoles(userID, time.Now())).
+			First(&user).Error
+	})
+
+	if err != nil {
+		if _, ok := err.(*appErrors.AppError); ok {
+			return nil, err
+		}
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Auth, "provider", provider)
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User signed in with OIDC", "userID", user.ID, "provider", provider)
+	return &user, nil
+}
+
+func (s *OIDCService) consumeState(ctx context.Context, provider, state string) (*models.OAuthState, error) {
+	var pending models.OAuthState
+	if err := s.oidc.DB.WithContext(ctx).
+		Where("state_hash = ?", utils.HashToken(state)).
+		First(&pending).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.NewAuth(codes.INVALID_OAUTH_STATE, err)
+		}
+		return nil, appErrors.FromDb(Auth, err)
+	}
+
+	// A state can only be redeemed once, concurrent callbacks lose the race here
+	result := s.oidc.DB.WithContext(ctx).Delete(&models.OAuthState{}, "id = ?", pending.ID)
+	if result.Error != nil {
+		return nil, appErrors.FromDb(Auth, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, appErrors.NewAuth(codes.INVALID_OAUTH_STATE, errOAuthStateConsumed)
+	}
+
+	if pending.Provider != provider || time.Now().After(pending.ExpiresAt) {
+		return nil, appErrors.NewAuth(codes.INVALID_OAUTH_STATE, errors.New("oauth state expired or issued for another provider"))
+	}
+
+	return &pending, nil
+}
+
+// resolveUser returns the id of the user linked to the identity in claims, linking or creating one when needed.
+func (s *OIDCService) resolveUser(tx *gorm.DB, provider string, claims *oidc.IDTokenClaims) (string, error) {
+	var identity models.UserIdentity
+	err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+	if err == nil {
+		return identity.UserID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	// Linking by an unverified email would let anyone claim an account at the provider
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return "", appErrors.NewAuth(codes.OIDC_EMAIL_NOT_VERIFIED, errors.New("provider did not verify the email"))
+	}
+
+	var user models.User
+	err = tx.Where("email = ?", claims.Email).First(&user).Error
+	switch {
+	case err == nil:
+		if user.ActivatedAt == nil {
+			if err := tx.Model(&user).Update("activated_at", time.Now()).Error; err != nil {
+				return "", err
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		created, err := s.createUser(tx, claims)
+		if err != nil {
+			return "", err
+		}
+		user = *created
+	default:
+		return "", err
+	}
+
+	if err := tx.Create(&models.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}).Error; err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
+
+func (s *OIDCService) createUser(tx *gorm.DB, claims *oidc.IDTokenClaims) (*models.User, error) {
+	if taken, err := emailTaken(tx, claims.Email, ""); err != nil {
+		return nil, err
+	} else if taken {
+		return nil, appErrors.NewAuth(codes.OIDC_LOGIN_FAILED, errors.New("email belongs to a deleted user"))
+	}
+
+	// Social accounts have no usable password until the user sets one through a reset
+	random, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := utils.HashPassword(random)
+	if err != nil {
+		return nil, err
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" {
+		firstName, lastName, _ = strings.Cut(claims.Name, " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	now := time.Now()
+	user := &models.User{
+		FirstName:         firstName,
+		LastName:          lastName,
+		Email:             claims.Email,
+		Password:          hashed,
+		ActivatedAt:       &now,
+		PasswordChangedAt: &now,
+	}
+
+	if err := tx.Create(user).Error; err != nil {
+		return nil, err
+	}
+
+	if err := assignDefaultRole(tx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
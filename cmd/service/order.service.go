@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/authz"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+)
+
+const Order = entities.ORDER
+
+// cancellableOrderStatuses are the statuses an order can still be cancelled from.
+var cancellableOrderStatuses = map[string]bool{"pending": true, "paid": true}
+
+type OrderService struct {
+	orders *models.OrderModel
+}
+
+// GetAll returns the orders subject may read, all of them for staff and their own for customers.
+func (s *OrderService) GetAll(ctx context.Context, subject authz.Subject) ([]models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	query, err := authz.Orders.Scope(s.orders.DB.WithContext(ctx), subject, authz.Read)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]models.Order, 0)
+	if err := query.Preload("Products").Order("ordered_at DESC").Find(&orders).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Order)
+		return nil, appErrors.FromDb(Order, err)
+	}
+
+	return orders, nil
+}
+
+// GetById returns an order subject may read.
+func (s *OrderService) GetById(ctx context.Context, subject authz.Subject, id string) (*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	order, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authz.Orders.Check(subject, authz.Read, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// Cancel cancels an order subject may cancel, as long as it has not shipped.
+func (s *OrderService) Cancel(ctx context.Context, subject authz.Subject, id string) (*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	order, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authz.Orders.Check(subject, authz.Cancel, order); err != nil {
+		return nil, err
+	}
+
+	if !cancellableOrderStatuses[order.Status] {
+		return nil, appErrors.New(Order, http.StatusConflict, errors.New("order can no longer be cancelled"))
+	}
+
+	if err := s.orders.DB.WithContext(ctx).Model(order).Update("status", "canceled").Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Order)
+		return nil, appErrors.FromDb(Order, err)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "Order cancelled", "orderID", order.ID, "by", subject.UserID)
+	return order, nil
+}
+
+func (s *OrderService) find(ctx context.Context, id string) (*models.Order, error) {
+	var order models.Order
+	if err := s.orders.DB.WithContext(ctx).Preload("Products").Where("id = ?", id).First(&order).Error; err != nil {
+		return nil, appErrors.FromDb(Order, err)
+	}
+
+	return &order, nil
+}
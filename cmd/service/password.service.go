@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/mailer"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+var errResetTokenConsumed = errors.New("password reset token already used")
+
+type PasswordService struct {
+	resets *models.PasswordResetModel
+	mailer mailer.Mailer
+	auth   *AuthService
+}
+
+// Forgot emails a password reset link when an account exists for email.
+// The work runs in the background so neither the response nor its timing
+// reveals whether the email is registered.
+func (s *PasswordService) Forgot(ctx context.Context, email string) {
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		if err := s.sendResetLink(ctx, email); err != nil {
+			logger.FromContext(ctx).ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		}
+	}()
+}
+
+func (s *PasswordService) sendResetLink(ctx context.Context, email string) error {
+	var user models.User
+	if err := s.resets.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := env.GetDurationEnv("PASSWORD_RESET_TTL", time.Hour)
+	err = s.resets.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the most recent link is valid
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(raw),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", env.GetStringEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"), url.QueryEscape(raw))
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s and can only be used once. If you did not ask for a reset you can ignore this email.",
+			user.FirstName, link, ttl,
+		),
+	})
+}
+
+// Reset sets a new password using a reset token and revokes every session of the user.
+// The password must already satisfy the password policy.
+func (s *PasswordService) Reset(ctx context.Context, token, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	var existing models.PasswordResetToken
+	if err := s.resets.DB.WithContext(ctx).
+		Where("token_hash = ?", utils.HashToken(token)).
+		First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.NewAuth(codes.INVALID_RESET_TOKEN, err)
+		}
+		return appErrors.FromDb(User, err)
+	}
+
+	if existing.UsedAt != nil || time.Now().After(existing.ExpiresAt) {
+		return appErrors.NewAuth(codes.INVALID_RESET_TOKEN, errors.New("password reset token used or expired"))
+	}
+
+	err := s.resets.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", existing.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errResetTokenConsumed
+		}
+
+		return changePassword(tx, existing.UserID, password)
+	})
+
+	if err != nil {
+		if errors.Is(err, errResetTokenConsumed) {
+			return appErrors.NewAuth(codes.INVALID_RESET_TOKEN, err)
+		}
+		if _, ok := err.(*appErrors.AppError); ok {
+			return err
+		}
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return appErrors.FromDb(User, err)
+	}
+
+	if err := s.auth.revokeAll(ctx, existing.UserID); err != nil {
+		return err
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User password reset", "userID", existing.UserID)
+	return nil
+}
+
+// changePassword sets a new password for a user after checking it against the
+// password history, and records it so it cannot be reused later.
+func changePassword(tx *gorm.DB, userID, password string) error {
+	policy := utils.PasswordPolicyFromEnv()
+
+	if policy.HistorySize > 0 {
+		var user models.User
+		if err := tx.Select("id", "password").Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		var history []models.PasswordHistory
+		if err := tx.Where("user_id = ?", userID).
+			Order("created_at DESC").
+			Limit(policy.HistorySize).
+			Find(&history).Error; err != nil {
+			return err
+		}
+
+		// The current password is checked too for accounts created before the history was kept
+		hashes := []string{user.Password}
+		for _, entry := range history {
+			hashes = append(hashes, entry.PasswordHash)
+		}
+
+		for _, hash := range hashes {
+			if utils.VerifyWithHashed(password, hash) == nil {
+				return appErrors.NewAuth(codes.PASSWORD_REUSED, fmt.Errorf("password matches one of the last %d passwords", policy.HistorySize))
+			}
+		}
+	}
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"password": hashed, "password_changed_at": time.Now(), "must_change_password": false}).Error; err != nil {
+		return err
+	}
+
+	return recordPasswordHistory(tx, userID, hashed)
+}
+
+// recordPasswordHistory stores hashed as the latest password of a user and
+// forgets the entries that fall outside the configured history size.
+func recordPasswordHistory(tx *gorm.DB, userID, hashed string) error {
+	size := utils.PasswordPolicyFromEnv().HistorySize
+	if size <= 0 {
+		return nil
+	}
+
+	if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hashed}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("user_id = ? AND id NOT IN (?)", userID,
+		tx.Model(&models.PasswordHistory{}).
+			Select("id").
+			Where("user_id = ?", userID).
+			Order("created_at DESC").
+			Limit(size),
+	).Delete(&models.PasswordHistory{}).Error
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/authz"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+)
+
+const Payment = entities.PAYMENT
+
+type PaymentService struct {
+	payments *models.PaymentModel
+}
+
+// GetAll returns the payments subject may read, all of them for staff and those of
+// their own orders for customers.
+func (s *PaymentService) GetAll(ctx context.Context, subject authz.Subject) ([]models.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	query, err := authz.Payments.Scope(s.payments.DB.WithContext(ctx), subject, authz.Read)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]models.Payment, 0)
+	if err := query.Preload("Order").Order("processed_at DESC").Find(&payments).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Payment)
+		return nil, appErrors.FromDb(Payment, err)
+	}
+
+	return payments, nil
+}
+
+// GetById returns a payment subject may read.
+func (s *PaymentService) GetById(ctx context.Context, subject authz.Subject, id string) (*models.Payment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var payment models.Payment
+	if err := s.payments.DB.WithContext(ctx).Preload("Order").Where("id = ?", id).First(&payment).Error; err != nil {
+		return nil, appErrors.FromDb(Payment, err)
+	}
+
+	if err := authz.Payments.Check(subject, authz.Read, &payment); err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
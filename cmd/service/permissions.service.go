@@ -2,26 +2,417 @@ package service
 
 import (
 	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
 )
 
 type PermissionService struct {
 	permissions *models.PermissionModel
+	access      *accessCache
 }
 
-func (s *PermissionService) GetPermissions(ctx context.Context) ([]*models.Permission, error) {
+// Access is what a user may currently do, resolved from their roles.
+type Access struct {
+	Permissions  []string
+	TokenVersion int
+	Suspended    bool
+}
+
+// GetPermissions returns the permissions grouped as in the catalog. Wildcard grants and
+// permissions missing from the catalog come last, under "Other".
+func (s *PermissionService) GetPermissions(ctx context.Context) ([]models.PermissionGroup, error) {
 	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
-	var permissions []*models.Permission
+	var permissions []models.Permission
+
+	if err := s.permissions.DB.WithContext(ctx).Order("name").Find(&permissions).Error; err != nil {
+		return nil, errors.FromDb(entities.PERMISSIONS, err)
+	}
+
+	order := make(map[string]int)
+	for _, info := range constants.Catalog {
+		if _, ok := order[info.Group]; !ok {
+			order[info.Group] = len(order)
+		}
+	}
+
+	groups := make([]models.PermissionGroup, len(order)+1)
+	for name, i := range order {
+		groups[i].Name = name
+	}
+	groups[len(order)].Name = "Other"
+
+	for _, perm := range permissions {
+		i, ok := order[perm.Group]
+		if !ok {
+			i = len(order)
+		}
+		groups[i].Permissions = append(groups[i].Permissions, perm)
+	}
+
+	grouped := make([]models.PermissionGroup, 0, len(groups))
+	for _, group := range groups {
+		if len(group.Permissions) > 0 {
+			grouped = append(grouped, group)
+		}
+	}
+
+	return grouped, nil
+}
+
+// Explain reports whether a user holds permission and why: every role granting it,
+// directly or through inheritance, and the rule refusing it otherwise.
+func (s *PermissionService) Explain(ctx context.Context, userID, permission string) (*models.PermissionCheck, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	wanted := constants.Permission(utils.NormalizePermission(permission))
+
+	var user models.User
+	if err := s.permissions.DB.WithContext(ctx).
+		Select("id", "suspended_at", "suspended_until", "must_change_password").
+		Where("id = ?", userID).
+		First(&user).Error; err != nil {
+		return nil, errors.FromDb(User, err)
+	}
+
+	var grants []models.UserRole
+	if err := s.permissions.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&grants).Error; err != nil {
+		return nil, errors.FromDb(entities.PERMISSIONS, err)
+	}
+
+	var roles []models.Role
+	if err := s.permissions.DB.WithContext(ctx).Preload("Permissions").Preload("Parents").Find(&roles).Error; err != nil {
+		return nil, errors.FromDb(entities.PERMISSIONS, err)
+	}
+	byID := make(map[string]*models.Role, len(roles))
+	for i := range roles {
+		byID[roles[i].ID] = &roles[i]
+	}
+
+	check := &models.PermissionCheck{UserID: userID, Permission: string(wanted), Grants: make([]models.PermissionGrant, 0)}
+	for _, grant := range grants {
+		role, ok := byID[grant.RoleID]
+		if !ok {
+			continue
+		}
+
+		walkRole(byID, role, nil, make(map[string]bool), func(holder *models.Role, via []string) {
+			for _, perm := range holder.Permissions {
+				match := grantMatch(perm.Name, wanted)
+				if match == "" {
+					continue
+				}
+
+				check.Grants = append(check.Grants, models.PermissionGrant{
+					Role:      role.Name,
+					Via:       via,
+					Granted:   perm.Name,
+					Match:     match,
+					ExpiresAt: grant.ExpiresAt,
+					Expired:   !grant.IsActive(now),
+				})
+			}
+		})
+	}
+
+	var active *models.PermissionGrant
+	for i := range check.Grants {
+		if !check.Grants[i].Expired {
+			active = &check.Grants[i]
+			break
+		}
+	}
+
+	switch {
+	case user.IsSuspended(now):
+		check.DeniedBy, check.Reason = "suspended", "the user is suspended and holds no permissions until it is lifted"
+	case user.MustChangePassword:
+		check.DeniedBy, check.Reason = "must_change_password", "the user holds no permissions until the temporary password is changed"
+	case active != nil && active.Match == "full_access":
+		check.Allowed, check.Reason = true, fmt.Sprintf("granted through full_access by role %s", active.Role)
+	case active != nil:
+		check.Allowed, check.Reason = true, fmt.Sprintf("granted by role %s (%s %s)", active.Role, active.Match, active.Granted)
+	case len(check.Grants) > 0:
+		check.DeniedBy, check.Reason = "grant_expired", fmt.Sprintf("role %s granted %s but the grant has expired", check.Grants[0].Role, wanted)
+	default:
+		check.DeniedBy, check.Reason = "not_granted", fmt.Sprintf("no role of the user grants %s", wanted)
+	}
+
+	return check, nil
+}
+
+// Effective lists the known permissions covered by granted, so clients do not have to
+// understand wildcards and implications.
+func (s *PermissionService) Effective(ctx context.Context, granted []string) (*models.MyPermissions, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
 
-	if err := s.permissions.DB.WithContext(ctx).Find(&permissions).Error; err != nil {
+	var names []string
+	if err := s.permissions.DB.WithContext(ctx).Model(&models.Permission{}).Order("name").Pluck("name", &names).Error; err != nil {
 		return nil, errors.FromDb(entities.PERMISSIONS, err)
 	}
 
-	return permissions, nil
+	effective := make([]string, 0)
+	for _, name := range names {
+		if !strings.Contains(name, "*") && utils.HasPermission(granted, constants.Permission(name)) {
+			effective = append(effective, name)
+		}
+	}
+
+	if granted == nil {
+		granted = make([]string, 0)
+	}
+
+	return &models.MyPermissions{Granted: granted, Effective: effective}, nil
+}
+
+// walkRole calls visit for role and every role it inherits from, with the chain of
+// roles leading there. seen stops the walk on roles already visited.
+func walkRole(byID map[string]*models.Role, role *models.Role, via []string, seen map[string]bool, visit func(holder *models.Role, via []string)) {
+	if seen[role.ID] {
+		return
+	}
+	seen[role.ID] = true
+
+	visit(role, via)
+
+	for _, parent := range role.Parents {
+		if next, ok := byID[parent.ID]; ok {
+			walkRole(byID, next, append(append([]string(nil), via...), next.Name), seen, visit)
+		}
+	}
+}
+
+// grantMatch returns how granted covers permission, or "" when it does not.
+func grantMatch(granted string, permission constants.Permission) string {
+	name := utils.NormalizePermission(granted)
+
+	switch {
+	case name == string(constants.FullAccess):
+		return "full_access"
+	case name == string(permission):
+		return "exact"
+	case strings.Contains(name, "*") && utils.MatchPermission(name, permission):
+		return "wildcard"
+	case utils.HasPermission([]string{name}, permission):
+		return "implied"
+	}
+
+	return ""
+}
+
+// Resolve returns the effective permissions and token version of a user. Results are
+// cached for PERMISSION_CACHE_TTL so most requests do not query the roles.
+func (s *PermissionService) Resolve(ctx context.Context, userID string) (*Access, error) {
+	if access, ok := s.access.get(userID); ok {
+		return access, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	generation := s.access.generation()
+
+	var user models.User
+	if err := s.permissions.DB.WithContext(ctx).
+		Select("id", "token_version", "suspended_at", "suspended_until", "must_change_password").
+		Where("id = ?", userID).
+		First(&user).Error; err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewAuth(codes.INVALID_TOKEN, stdErrors.New("user no longer exists"))
+		}
+		return nil, errors.FromDb(User, err)
+	}
+
+	// A temporary password grants nothing until it is changed
+	names := make([]string, 0)
+	if user.MustChangePassword {
+		access := &Access{TokenVersion: user.TokenVersion, Suspended: user.IsSuspended(time.Now()), Permissions: names}
+		s.access.set(userID, access, generation)
+		return access, nil
+	}
+
+	names, err := grantedPermissions(s.permissions.DB.WithContext(ctx), userID, time.Now())
+	if err != nil {
+		return nil, errors.FromDb(entities.PERMISSIONS, err)
+	}
+
+	access := &Access{Permissions: names, TokenVersion: user.TokenVersion, Suspended: user.IsSuspended(time.Now())}
+	s.access.set(userID, access, generation)
+
+	return access, nil
+}
+
+// StartCachePurger drops expired cached access every interval until ctx is done.
+func (s *PermissionService) StartCachePurger(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.access.purge(now)
+			}
+		}
+	}()
+}
+
+// grantedPermissions returns the permissions of the roles a user holds at now, including
+// the roles those inherit from.
+func grantedPermissions(db *gorm.DB, userID string, now time.Time) ([]string, error) {
+	var roleIDs []string
+	if err := activeRoleIDs(db, userID, now).Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil, err
+	}
+
+	// Roles carry the permissions of every role they inherit from
+	ancestorIDs, err := roleAncestorIDs(db, roleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	if err := db.
+		Model(&models.Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions rp ON rp.permission_id = permissions.id").
+		Where("rp.role_id IN ?", append(roleIDs, ancestorIDs...)).
+		Pluck("permissions.name", &names).Error; err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// activeRoleIDs selects the roles granted to user that have not expired at now. user is a
+// user ID or a subquery selecting one.
+func activeRoleIDs(db *gorm.DB, user any, now time.Time) *gorm.DB {
+	return db.
+		Table("user_roles").
+		Select("role_id").
+		Where("user_id IN (?)", user).
+		Where("expires_at IS NULL OR expires_at > ?", now)
+}
+
+// preloadActiveRoles preloads the Roles of user without the grants that expired at now,
+// which the sweeper only removes on its next run. Nested preloads such as
+// "Roles.Permissions" are added as usual.
+func preloadActiveRoles(user any, now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		grants := activeRoleIDs(db.Session(&gorm.Session{NewDB: true}), user, now)
+		return db.Preload("Roles", "roles.id IN (?)", grants)
+	}
+}
+
+// Invalidate drops the cached access of a user, after their roles or token version changed.
+func (s *PermissionService) Invalidate(userID string) {
+	s.access.invalidate(userID)
+}
+
+// InvalidateAll drops every cached access, after a role or its permissions changed.
+func (s *PermissionService) InvalidateAll() {
+	s.access.invalidateAll()
+}
+
+type accessEntry struct {
+	access    *Access
+	expiresAt time.Time
+}
+
+// accessCache keeps resolved access per user. The generation counter stops a lookup
+// that started before an invalidation from storing what it read.
+type accessCache struct {
+	mu      sync.Mutex
+	entries map[string]accessEntry
+	gen     uint64
+}
+
+func newAccessCache() *accessCache {
+	return &accessCache{entries: make(map[string]accessEntry)}
+}
+
+func (c *accessCache) get(userID string) (*Access, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, userID)
+		return nil, false
+	}
+
+	return entry.access, true
+}
+
+func (c *accessCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gen
+}
+
+func (c *accessCache) set(userID string, access *Access, generation uint64) {
+	ttl := env.GetDurationEnv("PERMISSION_CACHE_TTL", 30*time.Second)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.gen {
+		return
+	}
+
+	c.entries[userID] = accessEntry{access: access, expiresAt: time.Now().Add(ttl)}
+}
+
+// purge drops the entries expired at now, so users who stopped making requests do not
+// stay in memory.
+func (c *accessCache) purge(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for userID, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, userID)
+		}
+	}
+}
+
+func (c *accessCache) invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	delete(c.entries, userID)
+}
+
+func (c *accessCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.entries = make(map[string]accessEntry)
 }
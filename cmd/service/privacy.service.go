@@ -0,0 +1,609 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/mailer"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const Privacy = entities.PRIVACY
+
+// PrivacyService answers data protection requests: exporting the data held about
+// a user and erasing their account.
+type PrivacyService struct {
+	privacy *models.PrivacyModel
+	auth    *AuthService
+	mailer  mailer.Mailer
+}
+
+// exportedOrder leaves the user out of every order of the archive, it is in profile.json.
+type exportedOrder struct {
+	models.Order
+	User *models.User `json:"user,omitempty"`
+}
+
+// exportedPayment leaves the order out of every payment of the archive, it is in orders.json.
+type exportedPayment struct {
+	models.Payment
+	Order *models.Order `json:"order,omitempty"`
+}
+
+type archiveFile struct {
+	name string
+	data interface{}
+}
+
+type exportManifest struct {
+	UserID      string    `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// RequestExport starts a background job packaging the data of the user. The user
+// is emailed once the archive can be downloaded.
+func (s *PrivacyService) RequestExport(ctx context.Context, userID string) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	var pending int64
+	if err := s.privacy.DB.WithContext(ctx).
+		Model(&models.DataExport{}).
+		Where("user_id = ? AND status = ?", userID, models.DataExportPending).
+		Count(&pending).Error; err != nil {
+		return nil, appErrors.FromDb(Privacy, err)
+	}
+	if pending > 0 {
+		return nil, appErrors.New(Privacy, http.StatusConflict, errors.New("a data export is already being prepared"))
+	}
+
+	export := &models.DataExport{UserID: userID, Status: models.DataExportPending}
+	if err := s.privacy.DB.WithContext(ctx).Create(export).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Privacy)
+		return nil, appErrors.FromDb(Privacy, err)
+	}
+
+	jobCtx := context.WithoutCancel(ctx)
+	go s.runExport(jobCtx, export.ID)
+
+	log.InfoLogger.InfoContext(ctx, "Data export requested", "userID", userID, "exportID", export.ID)
+	return export, nil
+}
+
+// ListExports returns the exports of a user, newest first, without their archives.
+func (s *PrivacyService) ListExports(ctx context.Context, userID string) ([]models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	exports := make([]models.DataExport, 0)
+	if err := s.privacy.DB.WithContext(ctx).
+		Omit("archive").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&exports).Error; err != nil {
+		return nil, appErrors.FromDb(Privacy, err)
+	}
+
+	return exports, nil
+}
+
+// DownloadExport returns a ready export of the user together with its archive.
+func (s *PrivacyService) DownloadExport(ctx context.Context, userID, exportID string) (*models.DataExport, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	var export models.DataExport
+	if err := s.privacy.DB.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND status = ? AND expires_at > ?", exportID, userID, models.DataExportReady, time.Now()).
+		First(&export).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.New(Privacy, http.StatusNotFound, errors.New("export not found, not ready or expired"))
+		}
+		return nil, appErrors.FromDb(Privacy, err)
+	}
+
+	return &export, nil
+}
+
+func (s *PrivacyService) runExport(ctx context.Context, exportID string) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	var export models.DataExport
+	if err := s.privacy.DB.WithContext(ctx).Omit("archive").Where("id = ?", exportID).First(&export).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Privacy, "exportID", exportID)
+		return
+	}
+
+	archive, user, err := s.buildArchive(ctx, export.UserID)
+	now := time.Now()
+	expiresAt := now.Add(env.GetDurationEnv("DATA_EXPORT_TTL", 7*24*time.Hour))
+
+	// Only the first run finishes a pending export when a retry overlaps with it
+	updates := map[string]interface{}{"completed_at": now}
+	if err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Privacy, "exportID", exportID)
+		updates["status"] = models.DataExportFailed
+		updates["error"] = "the archive could not be created"
+	} else {
+		updates["status"] = models.DataExportReady
+		updates["archive"] = archive
+		updates["size"] = len(archive)
+		updates["expires_at"] = expiresAt
+	}
+
+	result := s.privacy.DB.WithContext(ctx).
+		Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", exportID, models.DataExportPending).
+		Updates(updates)
+	if result.Error != nil {
+		log.ErrLogger.ErrorContext(ctx, result.Error.Error(), "entity", Privacy, "exportID", exportID)
+		return
+	}
+	if result.RowsAffected == 0 || err != nil {
+		return
+	}
+
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your data export is ready",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThe copy of your data you asked for is ready. Sign in and download it from your account before %s.",
+			user.FirstName, expiresAt.Format(time.RFC1123),
+		),
+	}); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Privacy)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "Data export ready", "userID", export.UserID, "exportID", exportID)
+}
+
+// buildArchive packages the profile, orders, payments and customer records of a user
+// as JSON files in a ZIP archive.
+func (s *PrivacyService) buildArchive(ctx context.Context, userID string) ([]byte, *models.User, error) {
+	db := s.privacy.DB.WithContext(ctx)
+
+	var user models.User
+	if err := db.Scopes(preloadActiveRoles(userID, time.Now())).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var orders []models.Order
+	if err := db.Preload("Products").Where("user_id = ?", userID).Order("ordered_at").Find(&orders).Error; err != nil {
+		return nil, nil, err
+	}
+	exportedOrders := make([]exportedOrder, len(orders))
+	for i := range orders {
+		exportedOrders[i] = exportedOrder{Order: orders[i]}
+	}
+
+	var payments []models.Payment
+	if err := db.
+		Joins("JOIN orders ON orders.id = payments.order_id").
+		Where("orders.user_id = ?", userID).
+		Order("payments.processed_at").
+		Find(&payments).Error; err != nil {
+		return nil, nil, err
+	}
+	exportedPayments := make([]exportedPayment, len(payments))
+	for i := range payments {
+		exportedPayments[i] = exportedPayment{Payment: payments[i]}
+	}
+
+	customers := make([]models.Customer, 0)
+	if err := db.Where("user_id = ?", userID).Find(&customers).Error; err != nil {
+		return nil, nil, err
+	}
+
+	files := []archiveFile{
+		{"profile.json", user},
+		{"orders.json", exportedOrders},
+		{"payments.json", exportedPayments},
+		{"customer.json", customers},
+	}
+
+	manifest := exportManifest{UserID: userID, GeneratedAt: time.Now()}
+	for _, f := range files {
+		manifest.Files = append(manifest.Files, f.name)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range append(files, archiveFile{"manifest.json", manifest}) {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(f.data); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), &user, nil
+}
+
+// RequestErasure schedules the erasure of the signed in user after ERASURE_COOLING_OFF.
+// The user can cancel it until then.
+func (s *PrivacyService) RequestErasure(ctx context.Context, userID string, req *models.RequestErasureRequest) (*models.ErasureRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.privacy.DB.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+	if err := utils.VerifyWithHashed(req.CurrentPassword, user.Password); err != nil {
+		return nil, appErrors.NewAuth(codes.INVALID_PASSWORD, errors.New("current password does not match"))
+	}
+
+	erasure, err := s.schedule(ctx, userID, userID, req.Reason, time.Now().Add(erasureCoolingOff()))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYour account and personal data will be erased on %s. Until then you can sign in and cancel the request.\n\nIf this was not you, sign in, cancel the request and change your password right away.",
+			user.FirstName, erasure.ScheduledFor.Format(time.RFC1123),
+		),
+	}); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Privacy)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "Erasure requested", "userID", userID, "scheduledFor", erasure.ScheduledFor)
+	return erasure, nil
+}
+
+// AdminErase schedules the erasure of a user on their behalf. With req.Immediate the
+// cooling-off period is skipped and the user is erased right away.
+func (s *PrivacyService) AdminErase(ctx context.Context, userID string, req *models.AdminErasureRequest) (*models.ErasureRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	actorID, _ := ctx.Value(constants.USER_ID_KEY).(string)
+	if actorID == userID {
+		return nil, appErrors.New(Privacy, http.StatusBadRequest, errors.New("cannot erase yourself through the admin override"))
+	}
+	if actorID == "" {
+		actorID, _ = ctx.Value(constants.API_KEY_ID_KEY).(string)
+	}
+
+	var user models.User
+	if err := s.privacy.DB.WithContext(ctx).Unscoped().Select("id").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	if err := requireCoversUser(ctx, s.privacy.DB.WithContext(ctx), userID); err != nil {
+		return nil, err
+	}
+
+	var erasure models.ErasureRequest
+	err := s.privacy.DB.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, models.ErasurePending).
+		First(&erasure).Error
+	switch {
+	case err == nil:
+		if !req.Immediate {
+			return nil, appErrors.New(Privacy, http.StatusConflict, errors.New("an erasure is already scheduled"))
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		scheduledFor := time.Now()
+		if !req.Immediate {
+			scheduledFor = scheduledFor.Add(erasureCoolingOff())
+		}
+
+		created, err := s.schedule(ctx, userID, actorID, req.Reason, scheduledFor)
+		if err != nil {
+			return nil, err
+		}
+		erasure = *created
+	default:
+		return nil, appErrors.FromDb(Privacy, err)
+	}
+
+	if req.Immediate {
+		if err := s.erase(ctx, &erasure); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.FromContext(ctx).InfoLogger.InfoContext(ctx, "Erasure scheduled by admin", "userID", userID, "immediate", req.Immediate)
+	return &erasure, nil
+}
+
+// CancelErasure cancels the pending erasure of a user.
+func (s *PrivacyService) CancelErasure(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	result := s.privacy.DB.WithContext(ctx).
+		Model(&models.ErasureRequest{}).
+		Where("user_id = ? AND status = ?", userID, models.ErasurePending).
+		Updates(map[string]interface{}{"status": models.ErasureCancelled, "cancelled_at": time.Now()})
+	if result.Error != nil {
+		return appErrors.FromDb(Privacy, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.New(Privacy, http.StatusNotFound, errors.New("no pending erasure"))
+	}
+
+	logger.FromContext(ctx).InfoLogger.InfoContext(ctx, "Erasure cancelled", "userID", userID)
+	return nil
+}
+
+// GetErasure returns the latest erasure request of a user.
+func (s *PrivacyService) GetErasure(ctx context.Context, userID string) (*models.ErasureRequest, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var erasure models.ErasureRequest
+	if err := s.privacy.DB.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		First(&erasure).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.New(Privacy, http.StatusNotFound, errors.New("no erasure requested"))
+		}
+		return nil, appErrors.FromDb(Privacy, err)
+	}
+
+	return &erasure, nil
+}
+
+func (s *PrivacyService) schedule(ctx context.Context, userID, requestedBy, reason string, scheduledFor time.Time) (*models.ErasureRequest, error) {
+	erasure := &models.ErasureRequest{
+		UserID:       userID,
+		Status:       models.ErasurePending,
+		Reason:       reason,
+		RequestedBy:  requestedBy,
+		ScheduledFor: scheduledFor,
+	}
+
+	err := s.privacy.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pending int64
+		if err := tx.Model(&models.ErasureRequest{}).
+			Where("user_id = ? AND status = ?", userID, models.ErasurePending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return appErrors.New(Privacy, http.StatusConflict, errors.New("an erasure is already scheduled"))
+		}
+
+		return tx.Create(erasure).Error
+	})
+	if err != nil {
+		if _, ok := err.(*appErrors.AppError); ok {
+			return nil, err
+		}
+		return nil, appErrors.FromDb(Privacy, err)
+	}
+
+	return erasure, nil
+}
+
+// erase anonymizes the user and their customer record, removes their credentials and
+// signs them out. Orders and payments stay for accounting, now tied to an anonymous user,
+// and so do suspension, impersonation and erasure records, without their free text.
+func (s *PrivacyService) erase(ctx context.Context, erasure *models.ErasureRequest) error {
+	log := logger.FromContext(ctx)
+	userID := erasure.UserID
+
+	var user models.User
+	if err := s.privacy.DB.WithContext(ctx).Unscoped().Where("id = ?", userID).First(&user).Error; err != nil {
+		return appErrors.FromDb(User, err)
+	}
+
+	if err := s.auth.revokeAll(ctx, userID); err != nil {
+		return err
+	}
+
+	random, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return appErrors.New(Privacy, http.StatusInternalServerError, err)
+	}
+	hashed, err := utils.HashPassword(random)
+	if err != nil {
+		return appErrors.New(Privacy, http.StatusInternalServerError, err)
+	}
+
+	now := time.Now()
+	err = s.privacy.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"first_name":           "Erased",
+			"last_name":            "User",
+			"email":                fmt.Sprintf("erased-%s@erased.invalid", userID),
+			"pending_email":        "",
+			"password":             hashed,
+			"birthday":             nil,
+			"suspension_reason":    "",
+			"must_change_password": false,
+			"deleted_at":           gorm.Expr("COALESCE(deleted_at, ?)", now),
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Customer{}).
+			Where("user_id = ?", userID).
+			Update("customer_card", gorm.Expr("'erased-' || id")).Error; err != nil {
+			return err
+		}
+
+		for _, record := range []interface{}{
+			&models.RefreshToken{},
+			&models.Session{},
+			&models.PasswordResetToken{},
+			&models.PasswordHistory{},
+			&models.UserMFA{},
+			&models.RecoveryCode{},
+			&models.UserIdentity{},
+			&models.DataExport{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.APIKey{}).
+			Where("created_by = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		// Audit records are kept, but free text about the user and where they signed in from is not
+		if err := tx.Model(&models.SuspensionHistory{}).
+			Where("user_id = ?", userID).
+			Update("reason", "").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ImpersonationLog{}).
+			Where("target_id = ?", userID).
+			Update("reason", "").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ImpersonationLog{}).
+			Where("actor_id = ?", userID).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ErasureRequest{}).
+			Where("user_id = ?", userID).
+			Update("reason", "").Error; err != nil {
+			return err
+		}
+
+		return tx.Model(erasure).Updates(map[string]interface{}{"status": models.ErasureCompleted, "completed_at": now}).Error
+	})
+	if err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Privacy, "userID", userID)
+		return appErrors.FromDb(Privacy, err)
+	}
+	erasure.Status = models.ErasureCompleted
+	erasure.CompletedAt = &now
+	erasure.Reason = ""
+	s.auth.permissions.Invalidate(userID)
+
+	if err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been deleted",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYour account and personal data have been erased. Records of past orders and payments are kept without your personal details as required for accounting.",
+			user.FirstName,
+		),
+	}); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Privacy)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User erased", "userID", userID, "erasureID", erasure.ID)
+	return nil
+}
+
+// StartJobs runs the privacy jobs every interval until ctx is cancelled: due erasures
+// are carried out, expired archives dropped and exports lost to a restart retried.
+func (s *PrivacyService) StartJobs(ctx context.Context, interval time.Duration, log *logger.AppLogger) {
+	ctx = context.WithValue(ctx, constants.LOGGER_KEY, log)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.runJobs(ctx, now)
+			}
+		}
+	}()
+}
+
+func (s *PrivacyService) runJobs(ctx context.Context, now time.Time) {
+	log := logger.FromContext(ctx)
+
+	var due []models.ErasureRequest
+	if err := s.privacy.DB.WithContext(ctx).
+		Where("status = ? AND scheduled_for <= ?", models.ErasurePending, now).
+		Find(&due).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, "failed to load due erasures", "error", err)
+	}
+	for i := range due {
+		if err := s.erase(ctx, &due[i]); err != nil {
+			log.ErrLogger.ErrorContext(ctx, "failed to erase user", "userID", due[i].UserID, "error", err)
+		}
+	}
+
+	expired := s.privacy.DB.WithContext(ctx).
+		Model(&models.DataExport{}).
+		Where("status = ? AND expires_at <= ?", models.DataExportReady, now).
+		Updates(map[string]interface{}{"status": models.DataExportExpired, "archive": nil})
+	if expired.Error != nil {
+		log.ErrLogger.ErrorContext(ctx, "failed to expire data exports", "error", expired.Error)
+	} else if expired.RowsAffected > 0 {
+		log.InfoLogger.InfoContext(ctx, "expired data exports", "count", expired.RowsAffected)
+	}
+
+	var stale []string
+	if err := s.privacy.DB.WithContext(ctx).
+		Model(&models.DataExport{}).
+		Where("status = ? AND created_at <= ?", models.DataExportPending, now.Add(-env.GetDurationEnv("DATA_EXPORT_RETRY_AFTER", 15*time.Minute))).
+		Pluck("id", &stale).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, "failed to load stale data exports", "error", err)
+	}
+	for _, id := range stale {
+		s.runExport(ctx, id)
+	}
+}
+
+// erasureCoolingOff is how long a requested erasure waits before it is carried out.
+func erasureCoolingOff() time.Duration {
+	return env.GetDurationEnv("ERASURE_COOLING_OFF", 14*24*time.Hour)
+}
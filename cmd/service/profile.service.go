@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/mailer"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ProfileService lets signed in users manage their own account.
+type ProfileService struct {
+	users        *models.UserModel
+	auth         *AuthService
+	verification *VerificationService
+	lockout      *LockoutService
+}
+
+// Update changes the fields of the profile that are set in req.
+func (s *ProfileService) Update(ctx context.Context, userID string, req *models.UpdateProfileRequest) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	updates := make(map[string]interface{})
+	if req.FirstName != nil {
+		updates["first_name"] = *req.FirstName
+	}
+	if req.LastName != nil {
+		updates["last_name"] = *req.LastName
+	}
+	if req.Birthday != nil {
+		updates["birthday"] = *req.Birthday
+	}
+
+	if len(updates) > 0 {
+		if err := s.users.DB.WithContext(ctx).
+			Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(updates).Error; err != nil {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+			return nil, appErrors.FromDb(User, err)
+		}
+	}
+
+	var user models.User
+	if err := s.users.DB.WithContext(ctx).
+		Where("id = ?", userID).
+		Scopes(preloadActiveRoles(userID, time.Now())).
+		First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	return &user, nil
+}
+
+// ChangePassword replaces the password of the user after checking the current one.
+// Every session is signed out, including the one of the caller.
+func (s *ProfileService) ChangePassword(ctx context.Context, userID string, req *models.ChangePasswordRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	if _, err := s.checkCurrentPassword(ctx, userID, req.CurrentPassword); err != nil {
+		return err
+	}
+
+	if err := s.users.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return changePassword(tx, userID, req.NewPassword)
+	}); err != nil {
+		if _, ok := err.(*appErrors.AppError); ok {
+			return err
+		}
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return appErrors.FromDb(User, err)
+	}
+
+	if err := s.auth.revokeAll(ctx, userID); err != nil {
+		return err
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User password changed", "userID", userID)
+	return nil
+}
+
+// ChangeEmail starts a change of email. The new email only replaces the current one once
+// the link sent to it is opened, and the current email is told about the request.
+func (s *ProfileService) ChangeEmail(ctx context.Context, userID string, req *models.ChangeEmailRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	user, err := s.checkCurrentPassword(ctx, userID, req.CurrentPassword)
+	if err != nil {
+		return err
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if strings.EqualFold(email, user.Email) {
+		return appErrors.New(User, http.StatusBadRequest, errors.New("new email is the current email"))
+	}
+
+	if taken, err := emailTaken(s.users.DB.WithContext(ctx), email, ""); err != nil {
+		return appErrors.FromDb(User, err)
+	} else if taken {
+		return appErrors.New(User, http.StatusConflict, fmt.Errorf("user with email %s already exists", email))
+	}
+
+	if err := s.users.DB.WithContext(ctx).Model(user).Update("pending_email", email).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return appErrors.FromDb(User, err)
+	}
+	user.PendingEmail = email
+
+	if err := s.verification.SendEmailChange(ctx, user); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return err
+	}
+
+	if err := s.verification.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nA change of the email address of your account to %s was requested. It takes effect once the new address is confirmed.\n\nIf this was not you, reset your password right away.",
+			user.FirstName, email,
+		),
+	}); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User email change requested", "userID", userID)
+	return nil
+}
+
+// checkCurrentPassword confirms the password of the user. Wrong passwords count toward the
+// same lockout as failed logins, so a stolen session cannot be used to guess the password.
+func (s *ProfileService) checkCurrentPassword(ctx context.Context, userID, password string) (*models.User, error) {
+	log := logger.FromContext(ctx)
+	now := time.Now()
+
+	var user models.User
+	if err := s.users.DB.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	if err := s.lockout.throttle(&user, now); err != nil {
+		return nil, err
+	}
+
+	if err := utils.VerifyWithHashed(password, user.Password); err != nil {
+		if err := s.lockout.recordFailure(ctx, &user, now); err != nil {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+			return nil, appErrors.FromDb(User, err)
+		}
+		return nil, appErrors.NewAuth(codes.INVALID_PASSWORD, errors.New("current password does not match"))
+	}
+
+	if err := s.lockout.resetFailures(ctx, &user); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	return &user, nil
+}
+
+// rejectImpersonation stops an impersonator from changing the credentials or taking the data of the impersonated user.
+func rejectImpersonation(ctx context.Context) error {
+	if actorID, ok := ctx.Value(constants.ACTOR_ID_KEY).(string); ok && actorID != "" {
+		return appErrors.NewAuth(codes.NOT_ALLOWED_WHILE_IMPERSONATING, errors.New("credential change with impersonation token"))
+	}
+
+	return nil
+}
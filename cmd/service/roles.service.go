@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -17,7 +18,8 @@ import (
 const Role = entities.ROLE
 
 type RoleService struct {
-	roles *models.RoleModel
+	roles       *models.RoleModel
+	permissions *PermissionService
 }
 
 // Get All Roles
@@ -26,8 +28,8 @@ func (s *RoleService) GetAll(ctx context.Context) (roles []*models.Role, err err
 	defer cancel()
 
 	log := logger.FromContext(ctx)
-	if err = s.roles.DB.WithContext(ctx).Preload("Permissions").Find(roles).Error; err != nil {
-		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+	if err = s.roles.DB.WithContext(ctx).Preload("Permissions").Preload("Parents").Find(&roles).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Role)
 		err = appErrors.FromDb(Role, err)
 	}
 
@@ -35,18 +37,39 @@ func (s *RoleService) GetAll(ctx context.Context) (roles []*models.Role, err err
 
 }
 
-// Get Single Role
-func (s *RoleService) GetRole(ctx context.Context, id string) (tRole *models.Role, err error) {
+// Get Single Role with the permissions it inherits
+func (s *RoleService) GetRole(ctx context.Context, id string) (*models.RoleDetail, error) {
 	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
 	log := logger.FromContext(ctx)
-	if err = s.roles.DB.WithContext(ctx).Where("id=?", id).Preload("Permissions").First(tRole).Error; err != nil {
-		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
-		err = appErrors.FromDb(Role, err)
+
+	var tRole models.Role
+	if err := s.roles.DB.WithContext(ctx).Where("id=?", id).Preload("Permissions").Preload("Parents").First(&tRole).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Role)
+		return nil, appErrors.FromDb(Role, err)
 	}
 
-	return
+	ancestors, err := roleAncestors(s.roles.DB.WithContext(ctx), []string{tRole.ID})
+	if err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Role)
+		return nil, appErrors.FromDb(Role, err)
+	}
+
+	inherited := make([]models.InheritedPermission, 0)
+	index := make(map[string]int)
+	for _, ancestor := range ancestors {
+		for _, perm := range ancestor.Permissions {
+			if i, ok := index[perm.ID]; ok {
+				inherited[i].From = append(inherited[i].From, ancestor.Name)
+				continue
+			}
+			index[perm.ID] = len(inherited)
+			inherited = append(inherited, models.InheritedPermission{Permission: perm, From: []string{ancestor.Name}})
+		}
+	}
+
+	return &models.RoleDetail{Role: tRole, InheritedPermissions: inherited}, nil
 }
 
 // Create Role
@@ -70,13 +93,13 @@ func (s *RoleService) CreateRole(ctx context.Context, role *models.RoleRequest)
 	var roleResult models.Role
 	// Transaction for role creation + permission assignment
 	err = s.roles.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		newRole := models.Role{Name: role.Role}
+		newRole := models.Role{Name: role.Role, RequireMFA: role.RequireMFA}
 		if err := tx.Create(&newRole).Error; err != nil {
 			return err
 		}
 
 		var permissions []models.Permission
-		if err := tx.Where("name IN ?", role.Permissions).Find(&permissions).Error; err != nil {
+		if err := tx.Where("name IN ?", normalizePermissions(role.Permissions)).Find(&permissions).Error; err != nil {
 			return err
 		}
 
@@ -87,6 +110,18 @@ func (s *RoleService) CreateRole(ctx context.Context, role *models.RoleRequest)
 			newRole.Permissions = permissions
 		}
 
+		parents, err := findParentRoles(tx, role.Parents)
+		if err != nil {
+			return err
+		}
+
+		if len(parents) > 0 {
+			if err := tx.Model(&newRole).Association("Parents").Append(parents); err != nil {
+				return err
+			}
+			newRole.Parents = parents
+		}
+
 		// Return via closure capture
 		roleResult = newRole
 		return nil
@@ -94,6 +129,9 @@ func (s *RoleService) CreateRole(ctx context.Context, role *models.RoleRequest)
 
 	if err != nil {
 		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Role)
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			return nil, appErr
+		}
 		return nil, appErrors.FromDb(Role, err)
 	}
 
@@ -115,16 +153,32 @@ func (s *RoleService) UpdateRole(ctx context.Context, id string, role *models.Ro
 
 	//update the role name
 	err = s.roles.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		parents, err := findParentRoles(tx, role.Parents)
+		if err != nil {
+			return err
+		}
+
+		if err := checkInheritanceCycle(tx, existing.ID, parents); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&existing).Association("Parents").Replace(parents); err != nil {
+			return err
+		}
+
 		if err = tx.Model(&existing).Association("Permissions").Clear(); err != nil {
 			return err
 		}
 
-		if err = tx.Model(&existing).Update("name", role.Role).Error; err != nil {
+		if err = tx.Model(&existing).Updates(map[string]interface{}{
+			"name":        role.Role,
+			"require_mfa": role.RequireMFA,
+		}).Error; err != nil {
 			return err
 		}
 
 		var permissions []models.Permission
-		if err := tx.Where("name IN ?", role.Permissions).Find(&permissions).Error; err != nil {
+		if err := tx.Where("name IN ?", normalizePermissions(role.Permissions)).Find(&permissions).Error; err != nil {
 			return err
 		}
 
@@ -138,12 +192,20 @@ func (s *RoleService) UpdateRole(ctx context.Context, id string, role *models.Ro
 	})
 
 	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			return nil, appErr
+		}
 		return nil, appErrors.FromDb(Role, err)
 	}
 
+	// Holders of the role, and of the roles inheriting from it, get the new
+	// permissions on their next request
+	s.permissions.InvalidateAll()
+
 	// Reload updated record with associations
 	if err := s.roles.DB.WithContext(ctx).
 		Preload("Permissions").
+		Preload("Parents").
 		First(&existing, "id = ?", id).Error; err != nil {
 		return nil, appErrors.FromDb(Role, err)
 	}
@@ -177,12 +239,28 @@ func (s *RoleService) DeleteRole(ctx context.Context, id string) error {
 		return appErrors.New(Role, codes.ROLE_IN_USE, errors.New("Roles is in use"))
 	}
 
+	// Step 3: Check if any role inherits from this role
+	if err := s.roles.DB.WithContext(ctx).
+		Table("role_parents").
+		Where("parent_id = ?", id).
+		Count(&count).Error; err != nil {
+		return appErrors.FromDb(Role, err)
+	}
+
+	if count > 0 {
+		return appErrors.New(Role, codes.ROLE_IN_USE, errors.New("Role is inherited by other roles"))
+	}
+
 	err := s.roles.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Clear role-permission relationships
 		if err := tx.Model(&existing).Association("Permissions").Clear(); err != nil {
 			return err
 		}
 
+		if err := tx.Model(&existing).Association("Parents").Clear(); err != nil {
+			return err
+		}
+
 		// Delete the role
 		if err := tx.Delete(&existing).Error; err != nil {
 			return err
@@ -195,5 +273,92 @@ func (s *RoleService) DeleteRole(ctx context.Context, id string) error {
 		return appErrors.FromDb(Role, err)
 	}
 
+	s.permissions.InvalidateAll()
+
 	return nil
 }
+
+// findParentRoles loads the roles referenced by ID or name in refs.
+func findParentRoles(db *gorm.DB, refs []string) ([]models.Role, error) {
+	parents := make([]models.Role, 0, len(refs))
+	seen := make(map[string]bool)
+
+	for _, ref := range refs {
+		var parent models.Role
+		if err := db.Where("id = ? OR name = ?", ref, ref).First(&parent).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, appErrors.New(Role, http.StatusBadRequest, fmt.Errorf("parent role %s not found", ref))
+			}
+			return nil, err
+		}
+
+		if !seen[parent.ID] {
+			seen[parent.ID] = true
+			parents = append(parents, parent)
+		}
+	}
+
+	return parents, nil
+}
+
+// checkInheritanceCycle refuses parents when one of them is the role itself or
+// already inherits from it.
+func checkInheritanceCycle(db *gorm.DB, roleID string, parents []models.Role) error {
+	ids := make([]string, 0, len(parents))
+	for _, parent := range parents {
+		if parent.ID == roleID {
+			return appErrors.New(Role, codes.ROLE_INHERITANCE_CYCLE, errors.New("role cannot inherit from itself"))
+		}
+		ids = append(ids, parent.ID)
+	}
+
+	ancestors, err := roleAncestorIDs(db, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ancestors {
+		if id == roleID {
+			return appErrors.New(Role, codes.ROLE_INHERITANCE_CYCLE, errors.New("parents already inherit from the role"))
+		}
+	}
+
+	return nil
+}
+
+// roleAncestorIDs returns the IDs of every role the roles in ids inherit from, directly
+// or through other roles. UNION stops the recursion on roles already visited.
+func roleAncestorIDs(db *gorm.DB, ids []string) ([]string, error) {
+	ancestors := make([]string, 0)
+	if len(ids) == 0 {
+		return ancestors, nil
+	}
+
+	if err := db.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT parent_id FROM role_parents WHERE role_id IN ?
+			UNION
+			SELECT rp.parent_id FROM role_parents rp JOIN ancestors a ON rp.role_id = a.parent_id
+		)
+		SELECT parent_id FROM ancestors`, ids).
+		Scan(&ancestors).Error; err != nil {
+		return nil, err
+	}
+
+	return ancestors, nil
+}
+
+// roleAncestors returns the roles ids inherit from with their permissions.
+func roleAncestors(db *gorm.DB, ids []string) ([]models.Role, error) {
+	ancestorIDs, err := roleAncestorIDs(db, ids)
+	if err != nil || len(ancestorIDs) == 0 {
+		return nil, err
+	}
+
+	var ancestors []models.Role
+	if err := db.Preload("Permissions").Where("id IN ?", ancestorIDs).Order("name").Find(&ancestors).Error; err != nil {
+		return nil, err
+	}
+
+	return ancestors, nil
+}
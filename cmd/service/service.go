@@ -1,17 +1,55 @@
 package service
 
-import "github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+import (
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/mailer"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/oidc"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/revocation"
+)
 
 type Service struct {
-	UserService       *UserService
-	PermissionService *PermissionService
-	RoleService       *RoleService
+	UserService          *UserService
+	PermissionService    *PermissionService
+	RoleService          *RoleService
+	AuthService          *AuthService
+	VerificationService  *VerificationService
+	PasswordService      *PasswordService
+	MFAService           *MFAService
+	LockoutService       *LockoutService
+	OIDCService          *OIDCService
+	APIKeyService        *APIKeyService
+	SessionService       *SessionService
+	ImpersonationService *ImpersonationService
+	SuspensionService    *SuspensionService
+	ProfileService       *ProfileService
+	PrivacyService       *PrivacyService
+	OrderService         *OrderService
+	PaymentService       *PaymentService
 }
 
-func NewService(m *models.Models) *Service {
+func NewService(m *models.Models, revoked revocation.Store, mail mailer.Mailer, providers map[string]*oidc.Provider) *Service {
+	permissions := &PermissionService{m.Permissions, newAccessCache()}
+	auth := &AuthService{tokens: m.Tokens, revoked: revoked, sessions: m.Sessions, permissions: permissions}
+	verification := &VerificationService{m.Users, mail}
+	lockout := &LockoutService{m.Users, newAttemptTracker()}
+
 	return &Service{
-		UserService:       &UserService{m.Users},
-		PermissionService: &PermissionService{m.Permissions},
-		RoleService:       &RoleService{m.Roles},
+		UserService:          &UserService{m.Users, auth, mail},
+		PermissionService:    permissions,
+		RoleService:          &RoleService{m.Roles, permissions},
+		AuthService:          auth,
+		VerificationService:  verification,
+		PasswordService:      &PasswordService{m.PasswordResets, mail, auth},
+		MFAService:           &MFAService{m.MFA, auth, lockout, newAttemptTracker()},
+		LockoutService:       lockout,
+		OIDCService:          &OIDCService{m.OIDC, providers},
+		APIKeyService:        &APIKeyService{m.APIKeys, permissions},
+		SessionService:       &SessionService{m.Sessions, auth},
+		ImpersonationService: &ImpersonationService{m.Impersonations, permissions},
+		SuspensionService:    &SuspensionService{m.Users, auth},
+		ProfileService:       &ProfileService{m.Users, auth, verification, lockout},
+		PrivacyService:       &PrivacyService{m.Privacy, auth, mail},
+		OrderService:         &OrderService{m.Orders},
+		PaymentService:       &PaymentService{m.Payments},
 	}
 }
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const Session = entities.SESSION
+
+type SessionService struct {
+	sessions *models.SessionModel
+	auth     *AuthService
+}
+
+// List returns the sessions of a user that can still be refreshed, most recently used first.
+// currentSID marks the session of the caller and may be empty.
+func (s *SessionService) List(ctx context.Context, userID, currentSID string) ([]*models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	sessions := make([]*models.Session, 0)
+	if err := s.sessions.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, time.Now().Add(-utils.RefreshTokenTTL())).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Session)
+		return nil, appErrors.FromDb(Session, err)
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentSID
+	}
+
+	return sessions, nil
+}
+
+// Revoke signs one session of userID out. Sessions of other users are reported as not found.
+// Signing out another user requires every permission that user holds.
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if err := rejectImpersonation(ctx); err != nil {
+		return err
+	}
+
+	if callerID, _ := ctx.Value(constants.USER_ID_KEY).(string); callerID != userID {
+		if err := requireCoversUser(ctx, s.sessions.DB.WithContext(ctx), userID); err != nil {
+			return err
+		}
+	}
+
+	var session models.Session
+	if err := s.sessions.DB.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Session)
+		}
+		return appErrors.FromDb(Session, err)
+	}
+
+	if _, err := s.auth.RevokeSession(ctx, session.ID); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", Session)
+		return err
+	}
+
+	log.InfoLogger.InfoContext(ctx, "Session revoked", "userID", userID, "sessionID", session.ID)
+	return nil
+}
+
+// describeDevice turns a user agent into a short label such as "Chrome on Windows".
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox/", "Firefox"},
+		{"chrome/", "Chrome"},
+		{"safari/", "Safari"},
+		{"curl/", "curl"},
+		{"postman", "Postman"},
+		{"okhttp", "Android app"},
+		{"cfnetwork", "iOS app"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"iphone", "iPhone"},
+		{"ipad", "iPad"},
+		{"android", "Android"},
+		{"windows", "Windows"},
+		{"mac os x", "macOS"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max]
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/codes"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"gorm.io/gorm"
+)
+
+type SuspensionService struct {
+	users *models.UserModel
+	auth  *AuthService
+}
+
+// Suspend blocks a user from signing in until req.Until, or until the suspension is
+// lifted when no end date is given. Every session of the user ends immediately.
+func (s *SuspensionService) Suspend(ctx context.Context, userID string, req *models.SuspendRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	now := time.Now()
+	if req.Until != nil && !req.Until.After(now) {
+		return appErrors.New(User, http.StatusBadRequest, errors.New("until must be in the future"))
+	}
+
+	actorID, _ := ctx.Value(constants.USER_ID_KEY).(string)
+	if actorID == userID {
+		return appErrors.New(User, http.StatusBadRequest, errors.New("cannot suspend yourself"))
+	}
+
+	if err := requireCoversUser(ctx, s.users.DB.WithContext(ctx), userID); err != nil {
+		return err
+	}
+
+	err := s.users.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"suspended_at":      now,
+				"suspended_until":   req.Until,
+				"suspension_reason": req.Reason,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return recordSuspension(ctx, tx, userID, models.SuspensionActionSuspend, req.Reason, req.Until)
+	})
+
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		}
+		return appErrors.FromDb(User, err)
+	}
+
+	if err := s.auth.revokeAll(ctx, userID); err != nil {
+		return err
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User suspended", "targetID", userID, "until", req.Until, "reason", req.Reason)
+	return nil
+}
+
+// Unsuspend lifts the suspension of a user.
+func (s *SuspensionService) Unsuspend(ctx context.Context, userID string, req *models.UnsuspendRequest) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	err := s.users.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id", "suspended_at").Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		if user.SuspendedAt == nil {
+			return appErrors.New(User, http.StatusConflict, errors.New("user is not suspended"))
+		}
+
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"suspended_at":      nil,
+			"suspended_until":   nil,
+			"suspension_reason": "",
+		}).Error; err != nil {
+			return err
+		}
+
+		return recordSuspension(ctx, tx, userID, models.SuspensionActionUnsuspend, req.Reason, nil)
+	})
+
+	if err != nil {
+		if _, ok := err.(*appErrors.AppError); ok {
+			return err
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		}
+		return appErrors.FromDb(User, err)
+	}
+
+	s.auth.permissions.Invalidate(userID)
+
+	log.InfoLogger.InfoContext(ctx, "User unsuspended", "targetID", userID)
+	return nil
+}
+
+// History returns the suspension changes of a user, newest first.
+func (s *SuspensionService) History(ctx context.Context, userID string) ([]*models.SuspensionHistory, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	history := make([]*models.SuspensionHistory, 0)
+	if err := s.users.DB.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&history).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	return history, nil
+}
+
+func recordSuspension(ctx context.Context, tx *gorm.DB, userID, action, reason string, until *time.Time) error {
+	actorID, _ := ctx.Value(constants.USER_ID_KEY).(string)
+	apiKeyID, _ := ctx.Value(constants.API_KEY_ID_KEY).(string)
+
+	return tx.Create(&models.SuspensionHistory{
+		UserID:   userID,
+		Action:   action,
+		Reason:   reason,
+		Until:    until,
+		ActorID:  actorID,
+		APIKeyID: apiKeyID,
+	}).Error
+}
+
+// checkSuspended rejects users whose account is suspended.
+func checkSuspended(user *models.User) error {
+	if user.IsSuspended(time.Now()) {
+		return appErrors.NewAuth(codes.ACCOUNT_SUSPENDED, errors.New("account suspended"))
+	}
+
+	return nil
+}
@@ -7,18 +7,24 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
 	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/mailer"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const User = entities.USER
 
 type UserService struct {
-	users *models.UserModel
+	users  *models.UserModel
+	auth   *AuthService
+	mailer mailer.Mailer
 }
 
 func (s *UserService) GetAll(ctx context.Context) ([]*models.User, error) {
@@ -41,7 +47,8 @@ func (s *UserService) GetById(ctx context.Context, id string) (*models.User, err
 	var user models.User
 	if err := s.users.DB.WithContext(ctx).Where("id=?", id).
 		Preload("Roles.Permissions").
-		Preload("Roles").
+		Scopes(preloadActiveRoles(id, time.Now())).
+		Preload("RoleGrants").
 		First(&user).Error; err != nil {
 		log.ErrLogger.Error(err.Error(), "entity", User)
 		return nil, appErrors.FromDb(User, err)
@@ -56,14 +63,10 @@ func (s *UserService) Create(ctx context.Context, req *models.RegisterRequest) (
 
 	log := logger.FromContext(ctx)
 
-	var existing models.User
-	if err := s.users.DB.WithContext(ctx).
-		Select("id").
-		Where("email = ?", req.Email).
-		First(&existing).Error; err == nil {
-		return nil, appErrors.New(User, http.StatusConflict, fmt.Errorf("user with email %s already exists", req.Email))
-	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+	if taken, err := emailTaken(s.users.DB.WithContext(ctx), req.Email, ""); err != nil {
 		return nil, appErrors.FromDb(User, err)
+	} else if taken {
+		return nil, appErrors.New(User, http.StatusConflict, fmt.Errorf("user with email %s already exists", req.Email))
 	}
 
 	hashed, err := utils.HashPassword(req.Password)
@@ -71,34 +74,30 @@ func (s *UserService) Create(ctx context.Context, req *models.RegisterRequest) (
 		return nil, appErrors.FromDb(User, err)
 	}
 
+	now := time.Now()
 	user := &models.User{
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Email:     req.Email,
-		Password:  hashed,
+		FirstName:         req.FirstName,
+		LastName:          req.LastName,
+		Email:             req.Email,
+		Password:          hashed,
+		PasswordChangedAt: &now,
 	}
 
 	if err := s.users.DB.WithContext(ctx).Create(&user).Error; err != nil {
 		return nil, appErrors.FromDb(User, err)
 	}
 
-	var role models.Role
-	if err := s.users.DB.WithContext(ctx).
-		Preload("Permissions").
-		Where("name = ?", "user").
-		First(&role).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, appErrors.New(User, http.StatusInternalServerError, fmt.Errorf("default 'user' role not found"))
-		}
+	if err := recordPasswordHistory(s.users.DB.WithContext(ctx), user.ID, hashed); err != nil {
 		return nil, appErrors.FromDb(User, err)
 	}
 
-	if err := s.users.DB.WithContext(ctx).Model(&user).Association("Roles").Append(&role); err != nil {
-		return nil, appErrors.FromDb(User, err)
+	if err := assignDefaultRole(s.users.DB.WithContext(ctx), user); err != nil {
+		return nil, err
 	}
 
 	if err := s.users.DB.WithContext(ctx).
 		Preload("Roles.Permissions").
+		Scopes(preloadActiveRoles(user.ID, now)).
 		First(&user, "id = ?", user.ID).Error; err != nil {
 		return nil, appErrors.FromDb(User, err)
 	}
@@ -116,7 +115,7 @@ func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.Use
 	if err := s.users.DB.WithContext(ctx).
 		Where("email = ?", email).
 		Preload("Roles.Permissions").
-		Preload("Roles").
+		Scopes(preloadActiveRoles(s.users.DB.Model(&models.User{}).Select("id").Where("email = ?", email), time.Now())).
 		First(&user).Error; err != nil {
 
 		log.ErrLogger.Error(err.Error(), "entity", User)
@@ -125,3 +124,409 @@ func (s *UserService) GetByEmail(ctx context.Context, email string) (*models.Use
 
 	return &user, nil
 }
+
+// AdminCreate creates a staff user with a temporary password. The email counts as verified
+// and the user must change the password before any permission applies. The temporary
+// password is returned once and never stored in plain text.
+func (s *UserService) AdminCreate(ctx context.Context, granterPermissions []string, req *models.CreateUserRequest) (*models.CreatedUserResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if taken, err := emailTaken(s.users.DB.WithContext(ctx), req.Email, ""); err != nil {
+		return nil, appErrors.FromDb(User, err)
+	} else if taken {
+		return nil, appErrors.New(User, http.StatusConflict, fmt.Errorf("user with email %s already exists", req.Email))
+	}
+
+	roles := make([]*models.Role, 0, len(req.Roles))
+	for _, name := range uniqueStrings(req.Roles) {
+		role, err := findGrantableRole(s.users.DB.WithContext(ctx), name, granterPermissions)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	password, err := utils.GenerateTemporaryPassword(env.GetIntEnv("TEMPORARY_PASSWORD_LENGTH", 16))
+	if err != nil {
+		return nil, appErrors.New(User, http.StatusInternalServerError, err)
+	}
+
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	now := time.Now()
+	user := &models.User{
+		FirstName:          req.FirstName,
+		LastName:           req.LastName,
+		Email:              req.Email,
+		Password:           hashed,
+		ActivatedAt:        &now,
+		PasswordChangedAt:  &now,
+		MustChangePassword: true,
+	}
+
+	if err := s.users.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		if err := recordPasswordHistory(tx, user.ID, hashed); err != nil {
+			return err
+		}
+
+		if len(roles) == 0 {
+			return assignDefaultRole(tx, user)
+		}
+		return tx.Model(user).Association("Roles").Append(roles)
+	}); err != nil {
+		if _, ok := err.(*appErrors.AppError); ok {
+			return nil, err
+		}
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	created, err := s.GetById(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User created by admin", "userID", user.ID)
+	return &models.CreatedUserResponse{User: *created, TemporaryPassword: password}, nil
+}
+
+// Update changes the fields of a user that are set in req. An email set by an
+// administrator replaces the current one right away and drops any pending change.
+func (s *UserService) Update(ctx context.Context, id string, req *models.UpdateUserRequest) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	var user models.User
+	if err := s.users.DB.WithContext(ctx).Select("id", "email", "first_name").Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	if err := requireCoversUser(ctx, s.users.DB.WithContext(ctx), id); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.FirstName != nil {
+		updates["first_name"] = *req.FirstName
+	}
+	if req.LastName != nil {
+		updates["last_name"] = *req.LastName
+	}
+	if req.Birthday != nil {
+		updates["birthday"] = *req.Birthday
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		if taken, err := emailTaken(s.users.DB.WithContext(ctx), *req.Email, id); err != nil {
+			return nil, appErrors.FromDb(User, err)
+		} else if taken {
+			return nil, appErrors.New(User, http.StatusConflict, fmt.Errorf("user with email %s already exists", *req.Email))
+		}
+
+		updates["email"] = *req.Email
+		updates["pending_email"] = ""
+	}
+
+	if len(updates) > 0 {
+		if err := s.users.DB.WithContext(ctx).
+			Model(&models.User{}).
+			Where("id = ?", id).
+			Updates(updates).Error; err != nil {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+			return nil, appErrors.FromDb(User, err)
+		}
+
+		log.InfoLogger.InfoContext(ctx, "User updated by admin", "userID", id)
+	}
+
+	if _, changed := updates["email"]; changed {
+		// Sessions and reset links tied to the old address must not survive the change
+		if err := s.auth.revokeAll(ctx, id); err != nil {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		}
+
+		if err := s.mailer.Send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: "Your email address was changed",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nAn administrator changed the email address of your account to %s and signed you out everywhere.\n\nIf you did not expect this, contact support right away.",
+				user.FirstName, updates["email"],
+			),
+		}); err != nil {
+			log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		}
+	}
+
+	return s.GetById(ctx, id)
+}
+
+// Delete soft deletes a user and signs them out everywhere. Their orders and history are kept.
+func (s *UserService) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if callerID, _ := ctx.Value(constants.USER_ID_KEY).(string); callerID == id {
+		return appErrors.New(User, http.StatusBadRequest, errors.New("users cannot delete themselves"))
+	}
+
+	if err := requireCoversUser(ctx, s.users.DB.WithContext(ctx), id); err != nil {
+		return err
+	}
+
+	result := s.users.DB.WithContext(ctx).Where("id = ?", id).Delete(&models.User{})
+	if result.Error != nil {
+		log.ErrLogger.ErrorContext(ctx, result.Error.Error(), "entity", User)
+		return appErrors.FromDb(User, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.FromDb(User, gorm.ErrRecordNotFound)
+	}
+
+	if err := s.auth.revokeAll(ctx, id); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+	}
+
+	log.InfoLogger.InfoContext(ctx, "User deleted", "userID", id)
+	return nil
+}
+
+// AssignRole gives a user a role, found by ID or name. The caller must hold every
+// permission of the role so nobody can hand out more than they have. Granting a role
+// the user already has replaces the expiry of the grant.
+func (s *UserService) AssignRole(ctx context.Context, id, granterID string, granterPermissions []string, req *models.AssignRoleRequest) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, appErrors.New(User, http.StatusBadRequest, errors.New("expires_at must be in the future"))
+	}
+
+	var user models.User
+	if err := s.users.DB.WithContext(ctx).Select("id").Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	if err := requireCoversUser(ctx, s.users.DB.WithContext(ctx), id); err != nil {
+		return nil, err
+	}
+
+	role, err := findGrantableRole(s.users.DB.WithContext(ctx), req.Role, granterPermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	grant := models.UserRole{UserID: user.ID, RoleID: role.ID, ExpiresAt: req.ExpiresAt}
+	if granterID != "" {
+		grant.GrantedBy = &granterID
+	}
+
+	if err := s.users.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "role_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"expires_at", "granted_by"}),
+		}).
+		Create(&grant).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return nil, appErrors.FromDb(User, err)
+	}
+	s.auth.permissions.Invalidate(id)
+
+	log.InfoLogger.InfoContext(ctx, "Role assigned", "userID", id, "role", role.Name, "expiresAt", req.ExpiresAt)
+	return s.GetById(ctx, id)
+}
+
+// RemoveRole takes a role, found by ID or name, away from a user. Like AssignRole
+// the caller must hold every permission of the role.
+func (s *UserService) RemoveRole(ctx context.Context, id string, granterPermissions []string, roleRef string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	log := logger.FromContext(ctx)
+
+	var user models.User
+	if err := s.users.DB.WithContext(ctx).Select("id").Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	if err := requireCoversUser(ctx, s.users.DB.WithContext(ctx), id); err != nil {
+		return nil, err
+	}
+
+	role, err := findGrantableRole(s.users.DB.WithContext(ctx), roleRef, granterPermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := s.users.DB.WithContext(ctx).Model(&user).Where("roles.id = ?", role.ID).Association("Roles").Count()
+	if assigned == 0 {
+		return nil, appErrors.New(User, http.StatusNotFound, fmt.Errorf("user does not have role %s", role.Name))
+	}
+
+	if err := s.users.DB.WithContext(ctx).Model(&user).Association("Roles").Delete(role); err != nil {
+		log.ErrLogger.ErrorContext(ctx, err.Error(), "entity", User)
+		return nil, appErrors.FromDb(User, err)
+	}
+	s.auth.permissions.Invalidate(id)
+
+	log.InfoLogger.InfoContext(ctx, "Role removed", "userID", id, "role", role.Name)
+	return s.GetById(ctx, id)
+}
+
+// StartRoleGrantSweeper removes expired role grants every interval until ctx is cancelled.
+func (s *UserService) StartRoleGrantSweeper(ctx context.Context, interval time.Duration, log *logger.AppLogger) {
+	ctx = context.WithValue(ctx, constants.LOGGER_KEY, log)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.sweepRoleGrants(ctx, now)
+			}
+		}
+	}()
+}
+
+// sweepRoleGrants deletes the grants expired at now and signs their users out, so
+// sessions started under the role do not outlive it.
+func (s *UserService) sweepRoleGrants(ctx context.Context, now time.Time) {
+	log := logger.FromContext(ctx)
+
+	var expired []models.UserRole
+	if err := s.users.DB.WithContext(ctx).
+		Where("expires_at <= ?", now).
+		Find(&expired).Error; err != nil {
+		log.ErrLogger.ErrorContext(ctx, "failed to load expired role grants", "error", err)
+		return
+	}
+
+	affected := make(map[string]bool)
+	for _, grant := range expired {
+		result := s.users.DB.WithContext(ctx).
+			Where("user_id = ? AND role_id = ? AND expires_at <= ?", grant.UserID, grant.RoleID, now).
+			Delete(&models.UserRole{})
+		if result.Error != nil {
+			log.ErrLogger.ErrorContext(ctx, "failed to remove expired role grant", "userID", grant.UserID, "roleID", grant.RoleID, "error", result.Error)
+			continue
+		}
+
+		// The grant was extended since it was loaded
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		log.InfoLogger.InfoContext(ctx, "Role grant expired", "userID", grant.UserID, "roleID", grant.RoleID)
+		affected[grant.UserID] = true
+	}
+
+	for userID := range affected {
+		if err := s.auth.revokeAll(ctx, userID); err != nil {
+			log.ErrLogger.ErrorContext(ctx, "failed to sign out user after role grant expired", "userID", userID, "error", err)
+		}
+	}
+}
+
+// requireCoversUser refuses to act on a user holding a permission the caller lacks, so
+// nobody can take over, lock out or remove an account stronger than their own.
+func requireCoversUser(ctx context.Context, db *gorm.DB, targetID string) error {
+	callerPermissions, _ := ctx.Value(constants.PERMISSIONS_KEY).([]string)
+
+	granted, err := grantedPermissions(db, targetID, time.Now())
+	if err != nil {
+		return appErrors.FromDb(User, err)
+	}
+
+	for _, perm := range granted {
+		if !utils.HasPermission(callerPermissions, constants.Permission(perm)) {
+			return appErrors.New(User, http.StatusForbidden, fmt.Errorf("cannot act on a user holding %q", perm))
+		}
+	}
+
+	return nil
+}
+
+// findGrantableRole loads a role by ID or name and checks that permissions cover all of it.
+func findGrantableRole(db *gorm.DB, ref string, permissions []string) (*models.Role, error) {
+	var role models.Role
+	if err := db.Preload("Permissions").Where("id = ? OR name = ?", ref, ref).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.New(Role, http.StatusNotFound, fmt.Errorf("role %s not found", ref))
+		}
+		return nil, appErrors.FromDb(Role, err)
+	}
+
+	// The role also grants everything it inherits
+	ancestors, err := roleAncestors(db, []string{role.ID})
+	if err != nil {
+		return nil, appErrors.FromDb(Role, err)
+	}
+
+	for _, granted := range append([]models.Role{role}, ancestors...) {
+		for _, perm := range granted.Permissions {
+			if !utils.HasPermission(permissions, constants.Permission(perm.Name)) {
+				return nil, appErrors.New(Role, http.StatusForbidden, fmt.Errorf("cannot grant role %s without holding %q", role.Name, perm.Name))
+			}
+		}
+	}
+
+	return &role, nil
+}
+
+// emailTaken reports whether email belongs to a user other than exceptID. Deleted users
+// keep their email unless ALLOW_DELETED_EMAIL_REUSE is set.
+func emailTaken(db *gorm.DB, email, exceptID string) (bool, error) {
+	if !env.GetBoolEnv("ALLOW_DELETED_EMAIL_REUSE", false) {
+		db = db.Unscoped()
+	}
+
+	query := db.Model(&models.User{}).Where("email = ?", email)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// assignDefaultRole gives a newly created user the "user" role.
+func assignDefaultRole(db *gorm.DB, user *models.User) error {
+	var role models.Role
+	if err := db.
+		Preload("Permissions").
+		Where("name = ?", "user").
+		First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return appErrors.New(User, http.StatusInternalServerError, fmt.Errorf("default 'user' role not found"))
+		}
+		return appErrors.FromDb(User, err)
+	}
+
+	if err := db.Model(user).Association("Roles").Append(&role); err != nil {
+		return appErrors.FromDb(User, err)
+	}
+
+	return nil
+}
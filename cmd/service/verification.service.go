@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/mailer"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+)
+
+type VerificationService struct {
+	users  *models.UserModel
+	mailer mailer.Mailer
+}
+
+// Required reports whether unverified accounts are blocked from signing in.
+func (s *VerificationService) Required() bool {
+	return env.GetBoolEnv("REQUIRE_EMAIL_VERIFICATION", false)
+}
+
+// SendVerification emails the user a signed link that activates the account.
+func (s *VerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	return s.sendVerificationTo(ctx, user, user.Email, "If you did not create an account you can ignore this email.")
+}
+
+// SendEmailChange emails the pending email of the user a signed link that confirms the change.
+func (s *VerificationService) SendEmailChange(ctx context.Context, user *models.User) error {
+	return s.sendVerificationTo(ctx, user, user.PendingEmail, "If you did not ask to change your email you can ignore this email.")
+}
+
+func (s *VerificationService) sendVerificationTo(ctx context.Context, user *models.User, email, footer string) error {
+	ttl := env.GetDurationEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+
+	token, err := utils.GenerateActionToken(utils.PurposeVerifyEmail, user.ID, email, ttl)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/v1/auth/verify?token=%s", env.GetStringEnv("APP_BASE_URL", "http://localhost:8080"), url.QueryEscape(token))
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s. %s",
+			user.FirstName, link, ttl, footer,
+		),
+	})
+}
+
+// Verify activates the account a verification token was issued for, or completes
+// a change of email when the token was issued for the pending email.
+// Tokens issued for a previous email address of the user are rejected.
+func (s *VerificationService) Verify(ctx context.Context, token string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	claims, err := utils.VerifyActionToken(utils.PurposeVerifyEmail, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := s.users.DB.WithContext(ctx).Where("id = ?", claims.Subject).First(&user).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+
+	if user.PendingEmail != "" && claims.Email == user.PendingEmail {
+		return s.confirmEmailChange(ctx, &user)
+	}
+
+	if user.Email != claims.Email {
+		return nil, appErrors.InvalidToken(errors.New("email changed since token was issued"))
+	}
+
+	if user.ActivatedAt == nil {
+		now := time.Now()
+		if err := s.users.DB.WithContext(ctx).Model(&user).Update("activated_at", now).Error; err != nil {
+			return nil, appErrors.FromDb(User, err)
+		}
+		user.ActivatedAt = &now
+
+		logger.FromContext(ctx).InfoLogger.InfoContext(ctx, "User email verified", "userID", user.ID)
+	}
+
+	return &user, nil
+}
+
+func (s *VerificationService) confirmEmailChange(ctx context.Context, user *models.User) (*models.User, error) {
+	if taken, err := emailTaken(s.users.DB.WithContext(ctx), user.PendingEmail, user.ID); err != nil {
+		return nil, appErrors.FromDb(User, err)
+	} else if taken {
+		return nil, appErrors.New(User, http.StatusConflict, fmt.Errorf("user with email %s already exists", user.PendingEmail))
+	}
+
+	previous := user.Email
+	now := time.Now()
+	updates := map[string]interface{}{"email": user.PendingEmail, "pending_email": ""}
+	if user.ActivatedAt == nil {
+		updates["activated_at"] = now
+		user.ActivatedAt = &now
+	}
+
+	if err := s.users.DB.WithContext(ctx).Model(user).Updates(updates).Error; err != nil {
+		return nil, appErrors.FromDb(User, err)
+	}
+	user.Email, user.PendingEmail = user.PendingEmail, ""
+
+	logger.FromContext(ctx).InfoLogger.InfoContext(ctx, "User email changed", "userID", user.ID, "previousEmail", previous)
+	return user, nil
+}
+
+// Resend sends a new verification link. Unknown and already verified emails are
+// ignored so the response does not reveal which accounts exist.
+func (s *VerificationService) Resend(ctx context.Context, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := s.users.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return appErrors.FromDb(User, err)
+	}
+
+	if user.ActivatedAt != nil {
+		return nil
+	}
+
+	return s.SendVerification(ctx, &user)
+}
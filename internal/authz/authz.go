@@ -0,0 +1,130 @@
+// Package authz decides what a caller may do with a record. A policy combines the
+// permissions of the caller with conditions on the record, such as being its owner,
+// and applies them to single records as well as to list queries.
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Action is what a caller wants to do with a record.
+type Action string
+
+const (
+	Read   Action = "read"
+	Cancel Action = "cancel"
+)
+
+// Subject is the caller a decision is made for.
+type Subject struct {
+	// UserID is empty for API keys, they never own records
+	UserID      string
+	Permissions []string
+}
+
+// SubjectFromContext returns the caller set by the auth middleware.
+func SubjectFromContext(ctx context.Context) Subject {
+	userID, _ := ctx.Value(constants.USER_ID_KEY).(string)
+	permissions, _ := ctx.Value(constants.PERMISSIONS_KEY).([]string)
+
+	return Subject{UserID: userID, Permissions: permissions}
+}
+
+// Condition limits a rule to the records matching an attribute of the subject. Filter
+// and Match have to agree, so a record shows up in a list exactly when it can be read.
+type Condition[T any] struct {
+	Name string
+	// Filter narrows a query to the records the condition allows
+	Filter func(s Subject) clause.Expression
+	// Match reports whether a loaded record satisfies the condition
+	Match func(s Subject, record *T) bool
+}
+
+// Rule allows an action to holders of Permission, on every record when Condition is nil.
+type Rule[T any] struct {
+	Permission constants.Permission
+	Condition  *Condition[T]
+}
+
+// Policy lists the rules of every action on one kind of record. Rules are tried in
+// order, so unconditional rules go first.
+type Policy[T any] struct {
+	Entity string
+	Rules  map[Action][]Rule[T]
+}
+
+// Decision is the outcome of evaluating a policy.
+type Decision struct {
+	Allowed    bool   `json:"allowed"`
+	Permission string `json:"permission,omitempty"`
+	Condition  string `json:"condition,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// Evaluate decides whether s may perform action on record.
+func (p *Policy[T]) Evaluate(s Subject, action Action, record *T) Decision {
+	held := ""
+	for _, rule := range p.Rules[action] {
+		if !utils.HasPermission(s.Permissions, rule.Permission) {
+			continue
+		}
+
+		if rule.Condition == nil {
+			return Decision{Allowed: true, Permission: string(rule.Permission), Reason: "granted on every record"}
+		}
+		if rule.Condition.Match(s, record) {
+			return Decision{Allowed: true, Permission: string(rule.Permission), Condition: rule.Condition.Name, Reason: "granted by condition"}
+		}
+		held = string(rule.Permission)
+	}
+
+	if held != "" {
+		return Decision{Permission: held, Reason: "record does not match any condition of the held permissions"}
+	}
+
+	return Decision{Reason: fmt.Sprintf("no permission allows %s on %s", action, p.Entity)}
+}
+
+// Check returns an error unless s may perform action on record. A record the caller
+// has no claim on is reported as not found, so its existence is not leaked.
+func (p *Policy[T]) Check(s Subject, action Action, record *T) error {
+	decision := p.Evaluate(s, action, record)
+	if decision.Allowed {
+		return nil
+	}
+
+	if decision.Permission != "" {
+		return appErrors.New(p.Entity, http.StatusNotFound, fmt.Errorf("%s not found", p.Entity))
+	}
+
+	return appErrors.New(p.Entity, http.StatusForbidden, fmt.Errorf("%s", decision.Reason))
+}
+
+// Scope narrows db to the records s may perform action on.
+func (p *Policy[T]) Scope(db *gorm.DB, s Subject, action Action) (*gorm.DB, error) {
+	filters := make([]clause.Expression, 0)
+	for _, rule := range p.Rules[action] {
+		if !utils.HasPermission(s.Permissions, rule.Permission) {
+			continue
+		}
+
+		if rule.Condition == nil {
+			return db, nil
+		}
+		filters = append(filters, rule.Condition.Filter(s))
+	}
+
+	if len(filters) == 0 {
+		return nil, appErrors.New(p.Entity, http.StatusForbidden, fmt.Errorf("no permission allows %s on %s", action, p.Entity))
+	}
+
+	return db.Where(clause.Or(filters...)), nil
+}
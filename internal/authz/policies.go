@@ -0,0 +1,55 @@
+package authz
+
+import (
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"gorm.io/gorm/clause"
+)
+
+// orderOwner matches the orders placed by the subject.
+var orderOwner = &Condition[models.Order]{
+	Name: "owner",
+	Filter: func(s Subject) clause.Expression {
+		return clause.Eq{Column: clause.Column{Table: "orders", Name: "user_id"}, Value: s.UserID}
+	},
+	Match: func(s Subject, order *models.Order) bool {
+		return s.UserID != "" && order.UserID == s.UserID
+	},
+}
+
+// paymentOwner matches the payments of orders placed by the subject. Payments must
+// be loaded with their order.
+var paymentOwner = &Condition[models.Payment]{
+	Name: "owner",
+	Filter: func(s Subject) clause.Expression {
+		return clause.Expr{SQL: "payments.order_id IN (SELECT id FROM orders WHERE user_id = ?)", Vars: []interface{}{s.UserID}}
+	},
+	Match: func(s Subject, payment *models.Payment) bool {
+		return s.UserID != "" && payment.Order.UserID == s.UserID
+	},
+}
+
+var Orders = &Policy[models.Order]{
+	Entity: entities.ORDER,
+	Rules: map[Action][]Rule[models.Order]{
+		Read: {
+			{Permission: constants.ViewOrders},
+			{Permission: constants.ViewOwnOrders, Condition: orderOwner},
+		},
+		Cancel: {
+			{Permission: constants.CancelOrder},
+			{Permission: constants.CancelOwnOrder, Condition: orderOwner},
+		},
+	},
+}
+
+var Payments = &Policy[models.Payment]{
+	Entity: entities.PAYMENT,
+	Rules: map[Action][]Rule[models.Payment]{
+		Read: {
+			{Permission: constants.ViewPayments},
+			{Permission: constants.ViewOwnPayments, Condition: paymentOwner},
+		},
+	},
+}
@@ -0,0 +1,80 @@
+// Package catalog keeps the permissions table in line with constants.Catalog, the
+// permissions the code knows about.
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"gorm.io/gorm"
+)
+
+// Diff compares the permissions table with the catalog. Rows named after a wildcard
+// other than full access are role grants, not drift.
+func Diff(db *gorm.DB) (*models.PermissionDrift, error) {
+	var rows []models.Permission
+	if err := db.Order("name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]models.Permission, len(rows))
+	for _, row := range rows {
+		stored[row.Name] = row
+	}
+
+	drift := &models.PermissionDrift{Missing: make([]string, 0), Unknown: make([]string, 0), Outdated: make([]string, 0)}
+	known := make(map[string]bool, len(constants.Catalog))
+
+	for _, info := range constants.Catalog {
+		name := string(info.Name)
+		known[name] = true
+
+		row, ok := stored[name]
+		switch {
+		case !ok:
+			drift.Missing = append(drift.Missing, name)
+		case row.Group != info.Group || row.Description != info.Description || row.Danger != string(info.Danger):
+			drift.Outdated = append(drift.Outdated, name)
+		}
+	}
+
+	for _, row := range rows {
+		if !known[row.Name] && !strings.Contains(row.Name, "*") {
+			drift.Unknown = append(drift.Unknown, row.Name)
+		}
+	}
+	sort.Strings(drift.Missing)
+	sort.Strings(drift.Outdated)
+
+	return drift, nil
+}
+
+// Sync creates the missing permissions and updates the metadata of outdated ones. Unknown
+// permissions may still be granted to roles, so they are only reported. It returns the
+// drift found before syncing.
+func Sync(db *gorm.DB) (*models.PermissionDrift, error) {
+	drift, err := Diff(db)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, info := range constants.Catalog {
+			var perm models.Permission
+			if err := tx.Where(models.Permission{Name: string(info.Name)}).
+				Assign(models.Permission{Group: info.Group, Description: info.Description, Danger: string(info.Danger)}).
+				FirstOrCreate(&perm).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return drift, nil
+}
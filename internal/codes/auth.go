@@ -12,4 +12,67 @@ const (
 	LOGIN_SUCCESS
 
 	ROLE_IN_USE
+
+	INVALID_REFRESH_TOKEN
+	EXPIRED_REFRESH_TOKEN
+	REFRESH_TOKEN_REUSED
+	TOKEN_REFRESHED
+
+	TOKEN_REVOKED
+	LOGOUT_SUCCESS
+
+	EMAIL_NOT_VERIFIED
+	EMAIL_VERIFIED
+	VERIFICATION_SENT
+
+	INVALID_RESET_TOKEN
+	PASSWORD_RESET_SENT
+	PASSWORD_RESET
+
+	MFA_REQUIRED
+	INVALID_MFA_CODE
+	MFA_ALREADY_ENABLED
+	MFA_NOT_ENABLED
+	MFA_REQUIRED_BY_ROLE
+	MFA_ENROLLMENT_STARTED
+	MFA_ENABLED
+	MFA_DISABLED
+
+	TOO_MANY_LOGIN_ATTEMPTS
+	ACCOUNT_UNLOCKED
+
+	UNKNOWN_OIDC_PROVIDER
+	INVALID_OAUTH_STATE
+	OIDC_LOGIN_FAILED
+	OIDC_EMAIL_NOT_VERIFIED
+
+	INVALID_API_KEY
+	API_KEY_IP_NOT_ALLOWED
+
+	PASSWORD_REUSED
+
+	CANNOT_IMPERSONATE
+	IMPERSONATION_STARTED
+
+	ACCOUNT_SUSPENDED
+	USER_SUSPENDED
+	USER_UNSUSPENDED
+
+	NOT_ALLOWED_WHILE_IMPERSONATING
+	PROFILE_UPDATED
+	PASSWORD_CHANGED
+	EMAIL_CHANGE_REQUESTED
+
+	USER_CREATED
+	ROLE_ASSIGNED
+	ROLE_REMOVED
+
+	DATA_EXPORT_REQUESTED
+	ERASURE_SCHEDULED
+	ERASURE_CANCELLED
+	USER_ERASED
+
+	RATE_LIMITED
+
+	ROLE_INHERITANCE_CYCLE
 )
@@ -0,0 +1,91 @@
+package codes
+
+import "net/http"
+
+// httpStatus maps application codes to the HTTP status sent to the client.
+var httpStatus = map[int]int{
+	INVALID_TOKEN:     http.StatusUnauthorized,
+	EXPIRED_TOKEN:     http.StatusUnauthorized,
+	NO_TOKEN_PROVIDED: http.StatusUnauthorized,
+
+	INVALID_PASSWORD:          http.StatusBadRequest,
+	INVALID_EMAIL:             http.StatusBadRequest,
+	INVALID_EMAIL_OR_PASSWORD: http.StatusUnauthorized,
+
+	LOGIN_SUCCESS: http.StatusOK,
+
+	ROLE_IN_USE: http.StatusConflict,
+
+	INVALID_REFRESH_TOKEN: http.StatusUnauthorized,
+	EXPIRED_REFRESH_TOKEN: http.StatusUnauthorized,
+	REFRESH_TOKEN_REUSED:  http.StatusUnauthorized,
+	TOKEN_REFRESHED:       http.StatusOK,
+
+	TOKEN_REVOKED:  http.StatusUnauthorized,
+	LOGOUT_SUCCESS: http.StatusOK,
+
+	EMAIL_NOT_VERIFIED: http.StatusForbidden,
+	EMAIL_VERIFIED:     http.StatusOK,
+	VERIFICATION_SENT:  http.StatusOK,
+
+	INVALID_RESET_TOKEN: http.StatusBadRequest,
+	PASSWORD_RESET_SENT: http.StatusOK,
+	PASSWORD_RESET:      http.StatusOK,
+
+	MFA_REQUIRED:           http.StatusOK,
+	INVALID_MFA_CODE:       http.StatusUnauthorized,
+	MFA_ALREADY_ENABLED:    http.StatusConflict,
+	MFA_NOT_ENABLED:        http.StatusBadRequest,
+	MFA_REQUIRED_BY_ROLE:   http.StatusForbidden,
+	MFA_ENROLLMENT_STARTED: http.StatusOK,
+	MFA_ENABLED:            http.StatusOK,
+	MFA_DISABLED:           http.StatusOK,
+
+	TOO_MANY_LOGIN_ATTEMPTS: http.StatusTooManyRequests,
+	ACCOUNT_UNLOCKED:        http.StatusOK,
+
+	UNKNOWN_OIDC_PROVIDER:   http.StatusNotFound,
+	INVALID_OAUTH_STATE:     http.StatusBadRequest,
+	OIDC_LOGIN_FAILED:       http.StatusUnauthorized,
+	OIDC_EMAIL_NOT_VERIFIED: http.StatusForbidden,
+
+	INVALID_API_KEY:        http.StatusUnauthorized,
+	API_KEY_IP_NOT_ALLOWED: http.StatusForbidden,
+
+	PASSWORD_REUSED: http.StatusBadRequest,
+
+	CANNOT_IMPERSONATE:    http.StatusForbidden,
+	IMPERSONATION_STARTED: http.StatusOK,
+
+	ACCOUNT_SUSPENDED: http.StatusForbidden,
+	USER_SUSPENDED:    http.StatusOK,
+	USER_UNSUSPENDED:  http.StatusOK,
+
+	NOT_ALLOWED_WHILE_IMPERSONATING: http.StatusForbidden,
+	PROFILE_UPDATED:                 http.StatusOK,
+	PASSWORD_CHANGED:                http.StatusOK,
+	EMAIL_CHANGE_REQUESTED:          http.StatusAccepted,
+
+	USER_CREATED:  http.StatusCreated,
+	ROLE_ASSIGNED: http.StatusOK,
+	ROLE_REMOVED:  http.StatusOK,
+
+	DATA_EXPORT_REQUESTED: http.StatusAccepted,
+	ERASURE_SCHEDULED:     http.StatusAccepted,
+	ERASURE_CANCELLED:     http.StatusOK,
+	USER_ERASED:           http.StatusOK,
+
+	RATE_LIMITED: http.StatusTooManyRequests,
+
+	ROLE_INHERITANCE_CYCLE: http.StatusConflict,
+}
+
+// HTTPStatus returns the HTTP status for an application code.
+// Codes that are already HTTP statuses are returned unchanged.
+func HTTPStatus(code int) int {
+	if status, ok := httpStatus[code]; ok {
+		return status
+	}
+
+	return code
+}
@@ -3,9 +3,14 @@ package constants
 type ctxKey string
 
 const (
-	TRACE_ID_KEY   ctxKey = "trace_id"
-	REQUEST_ID_KEY ctxKey = "request_id"
-	USER_ID_KEY    ctxKey = "user_id"
+	TRACE_ID_KEY    ctxKey = "trace_id"
+	REQUEST_ID_KEY  ctxKey = "request_id"
+	USER_ID_KEY     ctxKey = "user_id"
 	PERMISSIONS_KEY ctxKey = "permissions"
-	LOGGER_KEY     ctxKey = "logger_key"
+	CLAIMS_KEY      ctxKey = "claims"
+	LOGGER_KEY      ctxKey = "logger_key"
+	CLIENT_IP_KEY   ctxKey = "client_ip"
+	API_KEY_ID_KEY  ctxKey = "api_key_id"
+	USER_AGENT_KEY  ctxKey = "user_agent"
+	ACTOR_ID_KEY    ctxKey = "actor_id"
 )
@@ -1,43 +1,173 @@
 package constants
 
+// Permission names are namespaced as resource:action, and actions can be nested
+// further such as orders:status:update. A grant can use * for one segment, or as
+// the last segment for everything below it: orders:* covers orders:status:update.
 type Permission string
 
 const (
 	// Admin
-	FullAccess Permission = "full_access"
+	FullAccess Permission = "*"
 
 	// Products
-	ViewProducts     Permission = "view_products"
-	CreateProduct    Permission = "create_product"
-	UpdateProduct    Permission = "update_product"
-	DeleteProduct    Permission = "delete_product"
-	UpdateInventory  Permission = "update_inventory"
+	ViewProducts    Permission = "products:read"
+	CreateProduct   Permission = "products:create"
+	UpdateProduct   Permission = "products:update"
+	DeleteProduct   Permission = "products:delete"
+	UpdateInventory Permission = "products:inventory:update"
 
 	// Orders
-	ViewOrders        Permission = "view_orders"
-	CreateOrder       Permission = "create_order"
-	UpdateOrderStatus Permission = "update_order_status"
-	CancelOrder       Permission = "cancel_order"
-	RefundOrder       Permission = "refund_order"
+	ViewOrders        Permission = "orders:read"
+	CreateOrder       Permission = "orders:create"
+	UpdateOrderStatus Permission = "orders:status:update"
+	CancelOrder       Permission = "orders:cancel"
+	RefundOrder       Permission = "orders:refund"
+	ViewOwnOrders     Permission = "orders:read:own"
+	CancelOwnOrder    Permission = "orders:cancel:own"
 
 	// Payments
-	ViewPayments   Permission = "view_payments"
-	CreatePayment  Permission = "create_payment"
-	RefundPayment  Permission = "refund_payment"
+	ViewPayments    Permission = "payments:read"
+	CreatePayment   Permission = "payments:create"
+	RefundPayment   Permission = "payments:refund"
+	ViewOwnPayments Permission = "payments:read:own"
 
 	// Users
-	ViewUsers   Permission = "view_users"
-	CreateUser  Permission = "create_user"
-	UpdateUser  Permission = "update_user"
-	DeleteUser  Permission = "delete_user"
-	BanUser     Permission = "ban_user"
+	ViewUsers       Permission = "users:read"
+	CreateUser      Permission = "users:create"
+	UpdateUser      Permission = "users:update"
+	DeleteUser      Permission = "users:delete"
+	BanUser         Permission = "users:ban"
+	ImpersonateUser Permission = "users:impersonate"
 
 	// Reports
-	ViewReports Permission = "view_reports"
-	ExportData  Permission = "export_data"
+	ViewReports Permission = "reports:read"
+	ExportData  Permission = "reports:export"
 
 	// System
-	ManageRoles       Permission = "manage_roles"
-	ManagePermissions Permission = "manage_permissions"
-	ManageSettings    Permission = "manage_settings"
+	ManageRoles       Permission = "roles:manage"
+	ManagePermissions Permission = "permissions:manage"
+	ManageSettings    Permission = "settings:manage"
+	ManageAPIKeys     Permission = "api_keys:manage"
 )
+
+// PermissionImplications lists the permissions a permission grants on top of itself.
+// Implications are followed transitively. A permission on every record implies the
+// matching :own permission, which only reaches the records of the caller.
+var PermissionImplications = map[Permission][]Permission{
+	CreateProduct:   {ViewProducts},
+	UpdateProduct:   {ViewProducts},
+	DeleteProduct:   {ViewProducts},
+	UpdateInventory: {ViewProducts},
+
+	ViewOrders:        {ViewOwnOrders},
+	UpdateOrderStatus: {ViewOrders},
+	CancelOrder:       {ViewOrders, CancelOwnOrder},
+	RefundOrder:       {ViewOrders, ViewPayments},
+	CancelOwnOrder:    {ViewOwnOrders},
+
+	ViewPayments:  {ViewOwnPayments},
+	RefundPayment: {ViewPayments},
+
+	CreateUser:      {ViewUsers},
+	UpdateUser:      {ViewUsers},
+	DeleteUser:      {ViewUsers},
+	BanUser:         {ViewUsers},
+	ImpersonateUser: {ViewUsers},
+
+	ExportData: {ViewReports},
+}
+
+// LegacyPermissions maps the names used before permissions were namespaced to their
+// current form. Stored grants are migrated, the map keeps older API keys working.
+var LegacyPermissions = map[string]Permission{
+	"full_access": FullAccess,
+
+	"view_products":    ViewProducts,
+	"create_product":   CreateProduct,
+	"update_product":   UpdateProduct,
+	"delete_product":   DeleteProduct,
+	"update_inventory": UpdateInventory,
+
+	"view_orders":         ViewOrders,
+	"create_order":        CreateOrder,
+	"update_order_status": UpdateOrderStatus,
+	"cancel_order":        CancelOrder,
+	"refund_order":        RefundOrder,
+
+	"view_payments":  ViewPayments,
+	"create_payment": CreatePayment,
+	"refund_payment": RefundPayment,
+
+	"view_users":       ViewUsers,
+	"create_user":      CreateUser,
+	"update_user":      UpdateUser,
+	"delete_user":      DeleteUser,
+	"ban_user":         BanUser,
+	"impersonate_user": ImpersonateUser,
+
+	"view_reports": ViewReports,
+	"export_data":  ExportData,
+
+	"manage_roles":       ManageRoles,
+	"manage_permissions": ManagePermissions,
+	"manage_settings":    ManageSettings,
+	"manage_api_keys":    ManageAPIKeys,
+}
+
+// DangerLevel is how much harm a permission can do in the wrong hands.
+type DangerLevel string
+
+const (
+	DangerLow      DangerLevel = "low"
+	DangerMedium   DangerLevel = "medium"
+	DangerHigh     DangerLevel = "high"
+	DangerCritical DangerLevel = "critical"
+)
+
+// PermissionInfo describes a permission for people assigning it.
+type PermissionInfo struct {
+	Name        Permission
+	Group       string
+	Description string
+	Danger      DangerLevel
+}
+
+// Catalog lists every permission known to the code, in display order. The permissions
+// table is synced from it, see the catalog package.
+var Catalog = []PermissionInfo{
+	{FullAccess, "Admin", "Every permission, present and future", DangerCritical},
+
+	{ViewProducts, "Products", "View products and their stock", DangerLow},
+	{CreateProduct, "Products", "Add products to the catalog", DangerMedium},
+	{UpdateProduct, "Products", "Edit product details and prices", DangerMedium},
+	{DeleteProduct, "Products", "Remove products from the catalog", DangerHigh},
+	{UpdateInventory, "Products", "Adjust stock levels", DangerMedium},
+
+	{ViewOrders, "Orders", "View every order", DangerMedium},
+	{ViewOwnOrders, "Orders", "View orders placed by yourself", DangerLow},
+	{CreateOrder, "Orders", "Place orders", DangerLow},
+	{UpdateOrderStatus, "Orders", "Move orders through fulfilment", DangerMedium},
+	{CancelOrder, "Orders", "Cancel any order", DangerHigh},
+	{CancelOwnOrder, "Orders", "Cancel orders placed by yourself", DangerLow},
+	{RefundOrder, "Orders", "Refund orders", DangerHigh},
+
+	{ViewPayments, "Payments", "View every payment", DangerMedium},
+	{ViewOwnPayments, "Payments", "View payments of your own orders", DangerLow},
+	{CreatePayment, "Payments", "Pay for orders", DangerLow},
+	{RefundPayment, "Payments", "Refund payments", DangerHigh},
+
+	{ViewUsers, "Users", "View user accounts", DangerMedium},
+	{CreateUser, "Users", "Create user accounts with a temporary password", DangerHigh},
+	{UpdateUser, "Users", "Edit accounts, assign roles and manage their sessions", DangerHigh},
+	{DeleteUser, "Users", "Delete and erase user accounts", DangerCritical},
+	{BanUser, "Users", "Suspend and unsuspend user accounts", DangerHigh},
+	{ImpersonateUser, "Users", "Sign in as another user", DangerCritical},
+
+	{ViewReports, "Reports", "View sales reports", DangerMedium},
+	{ExportData, "Reports", "Export report data", DangerHigh},
+
+	{ManageRoles, "System", "Create and edit roles and their permissions", DangerCritical},
+	{ManagePermissions, "System", "View and manage the permission catalog", DangerHigh},
+	{ManageSettings, "System", "Change application settings", DangerHigh},
+	{ManageAPIKeys, "System", "Create and revoke API keys", DangerCritical},
+}
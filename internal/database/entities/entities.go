@@ -9,4 +9,7 @@ const (
 	AUTHENTICATION = "authentication"
 	PERMISSIONS    = "permissions"
 	ROLE           = "role"
+	API_KEY        = "api_key"
+	SESSION        = "session"
+	PRIVACY        = "privacy"
 )
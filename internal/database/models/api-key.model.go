@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyModel struct {
+	DB *gorm.DB
+}
+
+// APIKey lets a service account call the API without a user session. Only the hash
+// of the key is stored, the key itself is shown once when it is created.
+type APIKey struct {
+	ID          string     `json:"id" gorm:"primaryKey;size:36"`
+	Name        string     `json:"name" gorm:"size:100;not null"`
+	Prefix      string     `json:"prefix" gorm:"size:16;not null"`
+	KeyHash     string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Permissions []string   `json:"permissions" gorm:"serializer:json;not null"`
+	AllowedIPs  []string   `json:"allowed_ips,omitempty" gorm:"serializer:json"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy   string     `json:"created_by" gorm:"size:36;not null"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name" validate:"required,min=2,max=100"`
+	Permissions []string   `json:"permissions" validate:"required,min=1,dive,required"`
+	AllowedIPs  []string   `json:"allowed_ips" validate:"omitempty,dive,ip|cidr"`
+	ExpiresAt   *time.Time `json:"expires_at" validate:"omitempty"`
+}
+
+// APIKeyCreatedResponse carries the plain key. It cannot be retrieved again.
+type APIKeyCreatedResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	if k.ID == "" {
+		k.ID = cuid.New()
+	}
+	return
+}
+
+func (r *CreateAPIKeyRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
@@ -16,9 +16,30 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=8"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	User         User   `json:"user"`
+
+	// PasswordChangeRequired is set when the password is older than the password policy allows
+	PasswordChangeRequired bool `json:"password_change_required,omitempty"`
 }
 
 func (r *RegisterRequest) Validate() error {
@@ -30,3 +51,23 @@ func (r *LoginRequest) Validate() error {
 	validate := validator.New()
 	return validate.Struct(r)
 }
+
+func (r *RefreshRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *ResendVerificationRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *ForgotPasswordRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *ResetPasswordRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
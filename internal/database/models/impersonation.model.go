@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type ImpersonationModel struct {
+	DB *gorm.DB
+}
+
+// ImpersonationLog records every time a staff member signed in as another user.
+// Its ID is the sid claim of the impersonation token.
+type ImpersonationLog struct {
+	ID          string    `json:"id" gorm:"primaryKey;size:36"`
+	ActorID     string    `json:"actor_id" gorm:"size:36;index;not null"`
+	TargetID    string    `json:"target_id" gorm:"size:36;index;not null"`
+	Reason      string    `json:"reason" gorm:"size:500;not null"`
+	Permissions []string  `json:"permissions" gorm:"serializer:json"`
+	IP          string    `json:"ip" gorm:"size:64"`
+	UserAgent   string    `json:"user_agent" gorm:"size:512"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"not null"`
+}
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=500"`
+}
+
+// ImpersonationResponse carries the access token of an impersonation. There is no
+// refresh token, a new impersonation has to be started once it expires.
+type ImpersonationResponse struct {
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Permissions []string  `json:"permissions"`
+	User        User      `json:"user"`
+}
+
+func (l *ImpersonationLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = cuid.New()
+	}
+	return
+}
+
+func (r *ImpersonateRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type MFAModel struct {
+	DB *gorm.DB
+}
+
+// UserMFA holds the TOTP secret of a user. Enrollment only takes effect once ConfirmedAt is set.
+type UserMFA struct {
+	UserID       string     `json:"user_id" gorm:"primaryKey;size:36"`
+	Secret       string     `json:"-" gorm:"size:64;not null"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	LastUsedStep int64      `json:"-" gorm:"default:0"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// RecoveryCode is a hashed single use code that replaces a TOTP code when the device is lost.
+type RecoveryCode struct {
+	ID        string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID    string     `json:"user_id" gorm:"size:36;index;not null"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFAChallengeResponse is returned by login instead of tokens when a second factor is needed.
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+}
+
+type MFAEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (c *RecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = cuid.New()
+	}
+	return
+}
+
+func (r *MFAVerifyRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *MFACodeRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
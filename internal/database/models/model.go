@@ -3,12 +3,20 @@ package models
 import "gorm.io/gorm"
 
 type Models struct {
-	Users       *UserModel
-	Products    *ProductModel
-	Orders      *OrderModel
-	Payments    *PaymentModel
-	Permissions *PermissionModel
-	Roles       *RoleModel
+	Users          *UserModel
+	Products       *ProductModel
+	Orders         *OrderModel
+	Payments       *PaymentModel
+	Permissions    *PermissionModel
+	Roles          *RoleModel
+	Tokens         *RefreshTokenModel
+	PasswordResets *PasswordResetModel
+	MFA            *MFAModel
+	OIDC           *OIDCModel
+	APIKeys        *APIKeyModel
+	Sessions       *SessionModel
+	Impersonations *ImpersonationModel
+	Privacy        *PrivacyModel
 }
 
 type Response struct {
@@ -16,6 +24,7 @@ type Response struct {
 	Message string      `json:"message"`
 	Code    int         `json:"code"`
 	Data    interface{} `json:"data,omitempty"`
+	Errors  []string    `json:"errors,omitempty"`
 }
 
 type ErrResponse struct {
@@ -26,11 +35,19 @@ type ErrResponse struct {
 
 func NewModel(db *gorm.DB) *Models {
 	return &Models{
-		Users:       &UserModel{db},
-		Products:    &ProductModel{db},
-		Payments:    &PaymentModel{db},
-		Orders:      &OrderModel{db},
-		Permissions: &PermissionModel{db},
-		Roles:       &RoleModel{db},
+		Users:          &UserModel{db},
+		Products:       &ProductModel{db},
+		Payments:       &PaymentModel{db},
+		Orders:         &OrderModel{db},
+		Permissions:    &PermissionModel{db},
+		Roles:          &RoleModel{db},
+		Tokens:         &RefreshTokenModel{db},
+		PasswordResets: &PasswordResetModel{db},
+		MFA:            &MFAModel{db},
+		OIDC:           &OIDCModel{db},
+		APIKeys:        &APIKeyModel{db},
+		Sessions:       &SessionModel{db},
+		Impersonations: &ImpersonationModel{db},
+		Privacy:        &PrivacyModel{db},
 	}
 }
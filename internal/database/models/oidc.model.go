@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type OIDCModel struct {
+	DB *gorm.DB
+}
+
+// OAuthState is a pending social login. It holds the nonce and PKCE verifier of
+// the attempt and is deleted when the provider redirects back.
+type OAuthState struct {
+	ID           string    `json:"id" gorm:"primaryKey;size:36"`
+	StateHash    string    `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	Provider     string    `json:"provider" gorm:"size:50;not null"`
+	Nonce        string    `json:"-" gorm:"size:64;not null"`
+	CodeVerifier string    `json:"-" gorm:"size:128;not null"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserIdentity links a user to their account at an OpenID Connect provider.
+type UserIdentity struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:36"`
+	UserID    string    `json:"user_id" gorm:"size:36;index;not null"`
+	Provider  string    `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject   string    `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *OAuthState) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = cuid.New()
+	}
+	return
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = cuid.New()
+	}
+	return
+}
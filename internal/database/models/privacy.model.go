@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type PrivacyModel struct {
+	DB *gorm.DB
+}
+
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired"
+)
+
+// DataExport is a copy of the data held about a user, packaged as a ZIP archive of
+// JSON files by a background job. The archive is dropped once the export expires.
+type DataExport struct {
+	ID          string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID      string     `json:"user_id" gorm:"size:36;index;not null"`
+	Status      string     `json:"status" gorm:"size:20;index;not null"`
+	Archive     []byte     `json:"-"`
+	Size        int        `json:"size"`
+	Error       string     `json:"error,omitempty" gorm:"size:500"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+const (
+	ErasurePending   = "pending"
+	ErasureCancelled = "cancelled"
+	ErasureCompleted = "completed"
+)
+
+// ErasureRequest schedules the anonymization of a user once the cooling-off period
+// has passed. Orders and payments are kept for accounting.
+type ErasureRequest struct {
+	ID           string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID       string     `json:"user_id" gorm:"size:36;index;not null"`
+	Status       string     `json:"status" gorm:"size:20;index;not null"`
+	Reason       string     `json:"reason,omitempty" gorm:"size:500"`
+	RequestedBy  string     `json:"requested_by" gorm:"size:36;not null"`
+	ScheduledFor time.Time  `json:"scheduled_for" gorm:"index;not null"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type RequestErasureRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	Reason          string `json:"reason" validate:"max=500"`
+}
+
+// AdminErasureRequest lets staff schedule an erasure for a user, or carry it out
+// right away without the cooling-off period.
+type AdminErasureRequest struct {
+	Reason    string `json:"reason" validate:"required,min=5,max=500"`
+	Immediate bool   `json:"immediate"`
+}
+
+func (e *DataExport) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = cuid.New()
+	}
+	return
+}
+
+func (e *ErasureRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = cuid.New()
+	}
+	return
+}
+
+func (r *RequestErasureRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *AdminErasureRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/lucsky/cuid"
 	"gorm.io/gorm"
 )
@@ -8,12 +10,18 @@ import (
 type Role struct {
 	ID          string       `gorm:"type:char(25);primaryKey" json:"id"`
 	Name        string       `gorm:"uniqueIndex;size:50;not null" json:"name" validate:"required,min=3,max=50"`
+	RequireMFA  bool         `gorm:"default:false" json:"require_mfa"`
 	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
+	// Parents are the roles this role inherits every permission from
+	Parents []Role `gorm:"many2many:role_parents;joinForeignKey:RoleID;joinReferences:ParentID" json:"parents,omitempty"`
 }
 
 type RoleRequest struct {
 	Role        string   `json:"role" validate:"required"`
+	RequireMFA  bool     `json:"require_mfa"`
 	Permissions []string `json:"permissions" validate:"required"`
+	// Parents are the IDs or names of the roles to inherit from
+	Parents []string `json:"parents"`
 }
 
 type RoleResponse struct {
@@ -21,14 +29,85 @@ type RoleResponse struct {
 	Data Role
 }
 
+// InheritedPermission is a permission a role holds through its parents.
+type InheritedPermission struct {
+	Permission
+	// From lists the ancestor roles granting the permission directly
+	From []string `json:"from"`
+}
+
+// RoleDetail is a role with its direct permissions and those it inherits.
+type RoleDetail struct {
+	Role
+	InheritedPermissions []InheritedPermission `json:"inherited_permissions"`
+}
+
 type Permission struct {
-	ID   string `gorm:"type:char(25);primaryKey" json:"id"`
-	Name string `gorm:"uniqueIndex;size:100;not null" json:"name" validate:"required,min=3,max=100"`
+	ID          string `gorm:"type:char(25);primaryKey" json:"id"`
+	Name        string `gorm:"uniqueIndex;size:100;not null" json:"name" validate:"required,min=3,max=100"`
+	Group       string `gorm:"column:group_name;size:50" json:"group,omitempty"`
+	Description string `gorm:"size:255" json:"description,omitempty"`
+	Danger      string `gorm:"size:20" json:"danger,omitempty"`
+}
+
+// PermissionGroup is a group of the permission catalog, such as Orders.
+type PermissionGroup struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// PermissionDrift lists where the permissions table disagrees with the catalog in code.
+type PermissionDrift struct {
+	// Missing are in the catalog but not in the database
+	Missing []string `json:"missing"`
+	// Unknown are in the database but not in the catalog, wildcard grants aside
+	Unknown []string `json:"unknown"`
+	// Outdated differ from the catalog in group, description or danger level
+	Outdated []string `json:"outdated"`
+}
+
+// Empty reports whether the database matches the catalog.
+func (d *PermissionDrift) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Unknown) == 0 && len(d.Outdated) == 0
+}
+
+// PermissionGrant is one way a user holds a permission.
+type PermissionGrant struct {
+	// Role is the role assigned to the user
+	Role string `json:"role"`
+	// Via is the chain of inherited roles leading from Role to the role storing Granted
+	Via []string `json:"via,omitempty"`
+	// Granted is the permission stored on the role
+	Granted string `json:"granted"`
+	// Match is how Granted covers the permission: exact, implied, wildcard or full_access
+	Match     string     `json:"match"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Expired   bool       `json:"expired,omitempty"`
+}
+
+// PermissionCheck explains whether a user holds a permission.
+type PermissionCheck struct {
+	UserID     string `json:"user_id"`
+	Permission string `json:"permission"`
+	Allowed    bool   `json:"allowed"`
+	// DeniedBy names the rule refusing the permission: suspended, must_change_password,
+	// grant_expired or not_granted
+	DeniedBy string            `json:"denied_by,omitempty"`
+	Reason   string            `json:"reason"`
+	Grants   []PermissionGrant `json:"grants"`
+}
+
+// MyPermissions are the permissions of the caller, for clients deciding what to show.
+type MyPermissions struct {
+	// Granted are the permissions carried by the token, wildcards included
+	Granted []string `json:"granted"`
+	// Effective are the known permissions Granted covers
+	Effective []string `json:"effective"`
 }
 
 type PermissionsResponse struct {
 	Response
-	Data []Permission
+	Data []PermissionGroup
 }
 
 type PermissionModel struct {
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionModel struct {
+	DB *gorm.DB
+}
+
+// Session is a signed in device. Its ID is the refresh token family and the sid
+// claim of the access tokens issued for it.
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID     string     `json:"user_id" gorm:"size:36;index;not null"`
+	Device     string     `json:"device" gorm:"size:100"`
+	UserAgent  string     `json:"user_agent" gorm:"size:512"`
+	IP         string     `json:"ip" gorm:"size:64"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	// Current marks the session of the caller when listing their own sessions
+	Current bool `json:"current" gorm:"-"`
+}
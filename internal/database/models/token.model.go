@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lucsky/cuid"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenModel struct {
+	DB *gorm.DB
+}
+
+// RefreshToken is a long-lived, single-use token. Every rotation creates a new
+// token in the same family so a replayed token can revoke the whole chain.
+type RefreshToken struct {
+	ID        string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID    string     `json:"user_id" gorm:"size:36;index;not null"`
+	FamilyID  string     `json:"family_id" gorm:"size:36;index;not null"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = cuid.New()
+	}
+	return
+}
+
+// RevokedToken is a revocation entry kept until the revoked tokens would have expired.
+type RevokedToken struct {
+	Key       string    `json:"key" gorm:"primaryKey;size:100"`
+	RevokedAt time.Time `json:"revoked_at" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+}
+
+type PasswordResetModel struct {
+	DB *gorm.DB
+}
+
+// PasswordResetToken is a single use token emailed to a user who forgot their password.
+type PasswordResetToken struct {
+	ID        string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID    string     `json:"user_id" gorm:"size:36;index;not null"`
+	TokenHash string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (t *PasswordResetToken) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = cuid.New()
+	}
+	return
+}
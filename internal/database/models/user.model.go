@@ -13,18 +13,60 @@ type UserModel struct {
 }
 
 type User struct {
-	ID          string     `json:"id" gorm:"primaryKey;size:36"`
-	FirstName   string     `json:"first_name" gorm:"size:100;not null" validate:"required,min=2"`
-	LastName    string     `json:"last_name" gorm:"size:100;not null" validate:"required,min=2"`
-	Email       string     `json:"email" gorm:"uniqueIndex;not null" validate:"required,email"`
-	Password    string     `json:"-" gorm:"not null" validate:"required,min=8"`
-	Birthday    *time.Time `json:"birthday,omitempty" validate:"omitempty,lte"`
-	ActivatedAt *time.Time `json:"activated_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          string         `json:"id" gorm:"primaryKey;size:36"`
+	FirstName   string         `json:"first_name" gorm:"size:100;not null" validate:"required,min=2"`
+	LastName    string         `json:"last_name" gorm:"size:100;not null" validate:"required,min=2"`
+	Email       string         `json:"email" gorm:"uniqueIndex:idx_users_email_active,where:deleted_at IS NULL;not null" validate:"required,email"`
+	Password    string         `json:"-" gorm:"not null" validate:"required,min=8"`
+	Birthday    *time.Time     `json:"birthday,omitempty" validate:"omitempty,lte"`
+	ActivatedAt *time.Time     `json:"activated_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// PendingEmail replaces Email once the link sent to it is opened
+	PendingEmail string `json:"pending_email,omitempty" gorm:"size:255"`
+
+	// MustChangePassword is set for temporary passwords. Until the password is
+	// changed the user holds no permissions and can only manage their own account.
+	MustChangePassword bool `json:"must_change_password" gorm:"not null;default:false"`
+
+	FailedLoginAttempts int        `json:"-" gorm:"not null;default:0"`
+	LastFailedLoginAt   *time.Time `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty" gorm:"size:500"`
+
+	// TokenVersion is carried by access tokens, raising it rejects every token issued before
+	TokenVersion int `json:"-" gorm:"not null;default:0"`
 
 	Orders []Order `json:"orders,omitempty" gorm:"foreignKey:UserID"`
 	Roles  []Role  `gorm:"many2many:user_roles;" json:"roles,omitempty"`
+	// RoleGrants are the user_roles rows behind Roles, with their expiry
+	RoleGrants []UserRole `json:"role_grants,omitempty" gorm:"foreignKey:UserID;-:migration"`
+}
+
+// UserRole is a role granted to a user. Grants with an expiry stop counting once it
+// passes, and the sweeper removes them and signs the user out.
+type UserRole struct {
+	UserID    string     `json:"user_id" gorm:"primaryKey;size:36"`
+	RoleID    string     `json:"role_id" gorm:"primaryKey;type:char(25)"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	GrantedBy *string    `json:"granted_by,omitempty" gorm:"size:36"`
+	GrantedAt time.Time  `json:"granted_at" gorm:"not null;default:CURRENT_TIMESTAMP;autoCreateTime"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// IsActive reports whether the grant still counts at now.
+func (g *UserRole) IsActive(now time.Time) bool {
+	return g.ExpiresAt == nil || g.ExpiresAt.After(now)
 }
 
 type UserResponse struct {
@@ -39,6 +81,142 @@ func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	return
 }
 
+// PasswordHistory keeps the hashes of earlier passwords so they cannot be reused.
+type PasswordHistory struct {
+	ID           string    `json:"id" gorm:"primaryKey;size:36"`
+	UserID       string    `json:"user_id" gorm:"size:36;index;not null"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (h *PasswordHistory) BeforeCreate(tx *gorm.DB) (err error) {
+	if h.ID == "" {
+		h.ID = cuid.New()
+	}
+	return
+}
+
+type UpdateProfileRequest struct {
+	FirstName *string    `json:"first_name" validate:"omitempty,min=2,max=100"`
+	LastName  *string    `json:"last_name" validate:"omitempty,min=2,max=100"`
+	Birthday  *time.Time `json:"birthday" validate:"omitempty,lte"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+type ChangeEmailRequest struct {
+	Email           string `json:"email" validate:"required,email"`
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+func (r *UpdateProfileRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *ChangePasswordRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *ChangeEmailRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+type CreateUserRequest struct {
+	FirstName string   `json:"first_name" validate:"required,min=2,max=100"`
+	LastName  string   `json:"last_name" validate:"required,min=2,max=100"`
+	Email     string   `json:"email" validate:"required,email"`
+	Roles     []string `json:"roles" validate:"omitempty,dive,required"`
+}
+
+// CreatedUserResponse carries the temporary password of a new user. It cannot be retrieved again.
+type CreatedUserResponse struct {
+	User              User   `json:"user"`
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+type UpdateUserRequest struct {
+	FirstName *string    `json:"first_name" validate:"omitempty,min=2,max=100"`
+	LastName  *string    `json:"last_name" validate:"omitempty,min=2,max=100"`
+	Email     *string    `json:"email" validate:"omitempty,email"`
+	Birthday  *time.Time `json:"birthday" validate:"omitempty,lte"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+	// ExpiresAt ends the grant automatically, it lasts until revoked when empty
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (r *CreateUserRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *UpdateUserRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *AssignRoleRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+// IsSuspended reports whether the account is suspended at now. A suspension
+// without an end date lasts until it is lifted.
+func (u *User) IsSuspended(now time.Time) bool {
+	return u.SuspendedAt != nil && (u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil))
+}
+
+// SuspensionHistory records every suspension and unsuspension of a user.
+type SuspensionHistory struct {
+	ID        string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID    string     `json:"user_id" gorm:"size:36;index;not null"`
+	Action    string     `json:"action" gorm:"size:20;not null"`
+	Reason    string     `json:"reason" gorm:"size:500"`
+	Until     *time.Time `json:"until,omitempty"`
+	ActorID   string     `json:"actor_id,omitempty" gorm:"size:36"`
+	APIKeyID  string     `json:"api_key_id,omitempty" gorm:"size:36"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+const (
+	SuspensionActionSuspend   = "suspend"
+	SuspensionActionUnsuspend = "unsuspend"
+)
+
+type SuspendRequest struct {
+	Reason string     `json:"reason" validate:"required,min=5,max=500"`
+	Until  *time.Time `json:"until" validate:"omitempty"`
+}
+
+type UnsuspendRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+func (h *SuspensionHistory) BeforeCreate(tx *gorm.DB) (err error) {
+	if h.ID == "" {
+		h.ID = cuid.New()
+	}
+	return
+}
+
+func (r *SuspendRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
+func (r *UnsuspendRequest) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
 func (u *User) Validate() error {
 	validate := validator.New()
 	return validate.Struct(u)
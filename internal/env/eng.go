@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -37,3 +38,31 @@ func GetIntEnv(key string, defaultValue int) int {
 
 	return defaultValue
 }
+
+func GetDurationEnv(key string, defaultValue time.Duration) time.Duration {
+
+	if val, ok := os.LookupEnv(key); ok {
+		duration, err := time.ParseDuration(val)
+
+		if err != nil {
+			return defaultValue
+		}
+		return duration
+	}
+
+	return defaultValue
+}
+
+func GetBoolEnv(key string, defaultValue bool) bool {
+
+	if val, ok := os.LookupEnv(key); ok {
+		boolVal, err := strconv.ParseBool(val)
+
+		if err != nil {
+			return defaultValue
+		}
+		return boolVal
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,272 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a JWT signing key. Keys without a private half are only used to verify
+// tokens signed before a rotation and are still published in the JWKS.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// Keyring holds every key tokens may be signed with, selected by the kid header.
+// Only the active key signs new tokens.
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+var defaultKeyring atomic.Pointer[Keyring]
+
+// SetDefault makes k the keyring used to sign and verify tokens.
+func SetDefault(k *Keyring) {
+	defaultKeyring.Store(k)
+}
+
+// Default returns the keyring set with SetDefault, or nil when none was set.
+func Default() *Keyring {
+	return defaultKeyring.Load()
+}
+
+// New builds a keyring from keys and signs with the key named activeID.
+func New(keys []*Key, activeID string) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*Key, len(keys))}
+
+	for _, key := range keys {
+		if _, ok := k.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+		k.keys[key.ID] = key
+	}
+
+	active, ok := k.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", activeID)
+	}
+	if active.Private == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeID)
+	}
+
+	k.active = active
+	return k, nil
+}
+
+// Load reads the keyring from PEM encoded keys, found in two places:
+//
+//   - the files in JWT_KEYS_DIR, each named after its kid, e.g. 2025-11.pem
+//   - environment variables named JWT_KEY_<kid>, e.g. JWT_KEY_2025_11 for kid 2025-11,
+//     so keys can be deployed as secrets without a key directory
+//
+// Each holds an RSA or Ed25519 private key, or only the public key of a retired key.
+// JWT_ACTIVE_KID picks the signing key and may be left out when there is a single
+// private key.
+//
+// Without any key an ephemeral Ed25519 key is generated, but only when APP_ENV is
+// explicitly "development" or "test", so a deployment that forgets to configure its
+// keys fails to start instead of signing with a throwaway key.
+func Load() (*Keyring, error) {
+	dir := env.GetStringEnv("JWT_KEYS_DIR", "")
+
+	keys, err := loadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fromEnv, err := loadEnv(os.Environ())
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, fromEnv...)
+
+	if dir == "" && len(keys) == 0 {
+		switch env.GetStringEnv("APP_ENV", "") {
+		case "development", "test":
+			return Ephemeral()
+		default:
+			return nil, errors.New("JWT_KEYS_DIR or a JWT_KEY_<kid> variable must be set unless APP_ENV is development or test")
+		}
+	}
+
+	var signers []string
+	for _, key := range keys {
+		if key.Private != nil {
+			signers = append(signers, key.ID)
+		}
+	}
+
+	activeID := env.GetStringEnv("JWT_ACTIVE_KID", "")
+	if activeID == "" {
+		if len(signers) != 1 {
+			return nil, fmt.Errorf("JWT_ACTIVE_KID must be set when %d private keys are configured", len(signers))
+		}
+		activeID = signers[0]
+	}
+
+	return New(keys, activeID)
+}
+
+// loadDir parses every .pem file in dir. An empty dir holds no keys.
+func loadDir(dir string) ([]*Key, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var keys []*Key
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParsePEM(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// envKeyPrefix starts the names of the environment variables holding keys.
+const envKeyPrefix = "JWT_KEY_"
+
+// loadEnv parses the keys in the JWT_KEY_<kid> variables of environ. The kid is the
+// rest of the name in lower case with underscores turned into dashes.
+func loadEnv(environ []string) ([]*Key, error) {
+	sort.Strings(environ)
+
+	var keys []*Key
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, envKeyPrefix) || value == "" {
+			continue
+		}
+
+		id := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, envKeyPrefix)), "_", "-")
+		key, err := ParsePEM(id, []byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Ephemeral returns a keyring with a single freshly generated Ed25519 key.
+// Tokens signed with it become invalid when the process restarts.
+func Ephemeral() (*Keyring, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		ID:      "ephemeral-" + hex.EncodeToString(id),
+		Method:  jwt.SigningMethodEdDSA,
+		Private: private,
+		Public:  public,
+	}
+
+	return New([]*Key{key}, key.ID)
+}
+
+// ParsePEM parses a PEM encoded RSA or Ed25519 key, private or public.
+func ParsePEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, Public: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+}
+
+// Active returns the key new tokens are signed with.
+func (k *Keyring) Active() *Key {
+	return k.active
+}
+
+// Lookup returns the key with the given kid.
+func (k *Keyring) Lookup(id string) (*Key, bool) {
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// Methods lists the signing algorithms of the keys in the ring.
+func (k *Keyring) Methods() []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, key := range k.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,313 @@
+package keyring
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func ed25519PrivatePEM(t *testing.T) ([]byte, ed25519.PublicKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), public
+}
+
+func publicPEM(t *testing.T, public any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func rsaPrivatePEM(t *testing.T, bits int) []byte {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+}
+
+func writeKeys(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestParsePEM(t *testing.T) {
+	edPrivate, edPublic := ed25519PrivatePEM(t)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantMethod  jwt.SigningMethod
+		wantPrivate bool
+		wantErr     string
+	}{
+		{name: "ed25519 private", data: edPrivate, wantMethod: jwt.SigningMethodEdDSA, wantPrivate: true},
+		{name: "ed25519 public", data: publicPEM(t, edPublic), wantMethod: jwt.SigningMethodEdDSA},
+		{name: "rsa private", data: rsaPrivatePEM(t, 2048), wantMethod: jwt.SigningMethodRS256, wantPrivate: true},
+		{name: "rsa too small", data: rsaPrivatePEM(t, 1024), wantErr: "at least 2048 bits"},
+		{name: "not pem", data: []byte("not a key"), wantErr: "no PEM block"},
+		{name: "unsupported block", data: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}), wantErr: "unsupported PEM block"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePEM("kid", tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePEM() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParsePEM() error = %v", err)
+			}
+			if key.ID != "kid" {
+				t.Errorf("ID = %q, want %q", key.ID, "kid")
+			}
+			if key.Method != tt.wantMethod {
+				t.Errorf("Method = %s, want %s", key.Method.Alg(), tt.wantMethod.Alg())
+			}
+			if (key.Private != nil) != tt.wantPrivate {
+				t.Errorf("has private key = %v, want %v", key.Private != nil, tt.wantPrivate)
+			}
+			if key.Public == nil {
+				t.Error("Public is nil")
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	current, _ := ed25519PrivatePEM(t)
+	next, _ := ed25519PrivatePEM(t)
+	_, retired := ed25519PrivatePEM(t)
+
+	tests := []struct {
+		name       string
+		files      map[string][]byte
+		env        map[string][]byte
+		noDir      bool
+		appEnv     string
+		activeKID  string
+		wantActive string
+		wantKeys   []string
+		wantErr    string
+	}{
+		{
+			name:       "single private key is active",
+			files:      map[string][]byte{"2025-11.pem": current},
+			wantActive: "2025-11",
+			wantKeys:   []string{"2025-11"},
+		},
+		{
+			name:       "retired public key is kept for verification",
+			files:      map[string][]byte{"2025-11.pem": current, "2025-05.pem": publicPEM(t, retired)},
+			wantActive: "2025-11",
+			wantKeys:   []string{"2025-05", "2025-11"},
+		},
+		{
+			name:       "active kid picks between private keys",
+			files:      map[string][]byte{"2025-11.pem": current, "2026-05.pem": next},
+			activeKID:  "2026-05",
+			wantActive: "2026-05",
+			wantKeys:   []string{"2025-11", "2026-05"},
+		},
+		{
+			name:    "several private keys need an active kid",
+			files:   map[string][]byte{"2025-11.pem": current, "2026-05.pem": next},
+			wantErr: "JWT_ACTIVE_KID must be set",
+		},
+		{
+			name:      "active kid must exist",
+			files:     map[string][]byte{"2025-11.pem": current},
+			activeKID: "2026-05",
+			wantErr:   `active key "2026-05" not found`,
+		},
+		{
+			name:      "active key must be able to sign",
+			files:     map[string][]byte{"2025-11.pem": current, "2025-05.pem": publicPEM(t, retired)},
+			activeKID: "2025-05",
+			wantErr:   `active key "2025-05" has no private key`,
+		},
+		{
+			name:    "invalid key file",
+			files:   map[string][]byte{"broken.pem": []byte("garbage")},
+			wantErr: "broken.pem",
+		},
+		{
+			name:       "keys from environment variables",
+			env:        map[string][]byte{"JWT_KEY_2025_11": current, "JWT_KEY_2025_05": publicPEM(t, retired)},
+			noDir:      true,
+			appEnv:     "production",
+			wantActive: "2025-11",
+			wantKeys:   []string{"2025-05", "2025-11"},
+		},
+		{
+			name:       "keys from a directory and environment variables",
+			files:      map[string][]byte{"2025-05.pem": publicPEM(t, retired)},
+			env:        map[string][]byte{"JWT_KEY_2025_11": current},
+			wantActive: "2025-11",
+			wantKeys:   []string{"2025-05", "2025-11"},
+		},
+		{
+			name:    "invalid environment key",
+			env:     map[string][]byte{"JWT_KEY_BROKEN": []byte("garbage")},
+			noDir:   true,
+			wantErr: "JWT_KEY_BROKEN",
+		},
+		{
+			name:       "ephemeral key in development",
+			noDir:      true,
+			appEnv:     "development",
+			wantActive: "ephemeral-",
+		},
+		{
+			name:    "no keys outside development",
+			noDir:   true,
+			appEnv:  "production",
+			wantErr: "must be set unless APP_ENV is development or test",
+		},
+		{
+			name:    "no keys without an environment",
+			noDir:   true,
+			wantErr: "must be set unless APP_ENV is development or test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := ""
+			if !tt.noDir {
+				dir = writeKeys(t, tt.files)
+			}
+			t.Setenv("JWT_KEYS_DIR", dir)
+			t.Setenv("JWT_ACTIVE_KID", tt.activeKID)
+			t.Setenv("APP_ENV", tt.appEnv)
+			for name, value := range tt.env {
+				t.Setenv(name, string(value))
+			}
+
+			ring, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !strings.HasPrefix(ring.Active().ID, tt.wantActive) {
+				t.Errorf("Active().ID = %q, want %q", ring.Active().ID, tt.wantActive)
+			}
+			for _, id := range tt.wantKeys {
+				if _, ok := ring.Lookup(id); !ok {
+					t.Errorf("Lookup(%q) found no key", id)
+				}
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	oldPrivate, oldPublic := ed25519PrivatePEM(t)
+	newPrivate, _ := ed25519PrivatePEM(t)
+
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_ACTIVE_KID", "")
+	t.Setenv("JWT_KEYS_DIR", writeKeys(t, map[string][]byte{"2025-05.pem": oldPrivate}))
+
+	before, err := Load()
+	if err != nil {
+		t.Fatalf("Load() before rotation error = %v", err)
+	}
+
+	token := jwt.NewWithClaims(before.Active().Method, jwt.RegisteredClaims{Subject: "user"})
+	token.Header["kid"] = before.Active().ID
+	signed, err := token.SignedString(before.Active().Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new key signs from now on, the old one only verifies until its tokens expire
+	t.Setenv("JWT_KEYS_DIR", writeKeys(t, map[string][]byte{
+		"2025-05.pem": publicPEM(t, oldPublic),
+		"2025-11.pem": newPrivate,
+	}))
+
+	after, err := Load()
+	if err != nil {
+		t.Fatalf("Load() after rotation error = %v", err)
+	}
+	if after.Active().ID != "2025-11" {
+		t.Errorf("Active().ID = %q, want %q", after.Active().ID, "2025-11")
+	}
+
+	parsed, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := after.Lookup(kid)
+		if !ok {
+			t.Fatalf("Lookup(%q) found no key", kid)
+		}
+		return key.Public, nil
+	}, jwt.WithValidMethods(after.Methods()))
+	if err != nil || !parsed.Valid {
+		t.Errorf("token signed before the rotation no longer verifies: %v", err)
+	}
+
+	kids := make(map[string]bool)
+	for _, jwk := range after.JWKS().Keys {
+		kids[jwk.Kid] = true
+	}
+	if !kids["2025-05"] || !kids["2025-11"] {
+		t.Errorf("JWKS() kids = %v, want both the retired and the active key", kids)
+	}
+}
+
+func TestNew(t *testing.T) {
+	ring, err := Ephemeral()
+	if err != nil {
+		t.Fatalf("Ephemeral() error = %v", err)
+	}
+	key := ring.Active()
+
+	if _, err := New([]*Key{key, key}, key.ID); err == nil || !strings.Contains(err.Error(), "duplicate key id") {
+		t.Errorf("New() with a duplicate kid error = %v, want a duplicate key id error", err)
+	}
+}
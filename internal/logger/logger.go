@@ -34,6 +34,15 @@ func (c CustomHandler) Handle(ctx context.Context, r slog.Record) error {
 		r.AddAttrs(slog.String(string(constants.USER_ID_KEY), val))
 	}
 
+	// Requests made while impersonating are logged with the impersonator as actor_id
+	if val, ok := ctx.Value(constants.ACTOR_ID_KEY).(string); ok {
+		r.AddAttrs(slog.String(string(constants.ACTOR_ID_KEY), val))
+	}
+
+	if val, ok := ctx.Value(constants.API_KEY_ID_KEY).(string); ok {
+		r.AddAttrs(slog.String(string(constants.API_KEY_ID_KEY), val))
+	}
+
 	if val, ok := ctx.Value(constants.TRACE_ID_KEY).(string); ok {
 		r.AddAttrs(slog.String(string(constants.TRACE_ID_KEY), val))
 	}
@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the mailer selected by MAILER ("smtp" or "file").
+// The file mailer is the default so local development never sends real email.
+func New() (Mailer, error) {
+	from := env.GetStringEnv("MAIL_FROM", "no-reply@shop.local")
+
+	switch kind := env.GetStringEnv("MAILER", "file"); kind {
+	case "smtp":
+		host := env.GetStringEnv("SMTP_HOST", "")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST not set")
+		}
+
+		return &SMTPMailer{
+			Host:     host,
+			Port:     env.GetIntEnv("SMTP_PORT", 587),
+			Username: env.GetStringEnv("SMTP_USERNAME", ""),
+			Password: env.GetStringEnv("SMTP_PASSWORD", ""),
+			From:     from,
+		}, nil
+	case "file":
+		return &FileMailer{
+			Dir:  env.GetStringEnv("MAIL_OUTBOX_DIR", "outbox"),
+			From: from,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", kind)
+	}
+}
+
+// format renders msg as an RFC 5322 message.
+func format(from string, msg Message) []byte {
+	return []byte(fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\n%s\r\n",
+		from, msg.To, msg.Subject, msg.Body,
+	))
+}
@@ -37,13 +37,25 @@ var errorRegistry = map[string]map[int]Message{
 	entities.ORDER: {
 		http.StatusNotFound: {
 			UserMessage: "Order not found.",
-			DevMessage:  "Order ID not found in DB.",
+			DevMessage:  "Order ID not found in DB, or the order belongs to another customer.",
+		},
+		http.StatusForbidden: {
+			UserMessage: "You do not have permission to perform this action.",
+			DevMessage:  "No authz rule of the order action is granted to the caller.",
+		},
+		http.StatusConflict: {
+			UserMessage: "This order can no longer be cancelled.",
+			DevMessage:  "Order status is past paid.",
 		},
 	},
 	entities.PAYMENT: {
 		http.StatusNotFound: {
 			UserMessage: "Payment not found.",
-			DevMessage:  "Payment record not found in DB.",
+			DevMessage:  "Payment record not found in DB, or it belongs to another customer.",
+		},
+		http.StatusForbidden: {
+			UserMessage: "You do not have permission to perform this action.",
+			DevMessage:  "No authz rule of the payment action is granted to the caller.",
 		},
 		http.StatusBadRequest: {
 			UserMessage: "Payment request is invalid.",
@@ -76,6 +88,94 @@ var errorRegistry = map[string]map[int]Message{
 			UserMessage: "Invalid password. Please check your password and try again.",
 			DevMessage:  "Password verification failed: password hash mismatch or validation error.",
 		},
+		codes.INVALID_REFRESH_TOKEN: {
+			UserMessage: "Invalid refresh token. Please sign in again.",
+			DevMessage:  "Refresh token not found or revoked.",
+		},
+		codes.EXPIRED_REFRESH_TOKEN: {
+			UserMessage: "Session expired. Please sign in again.",
+			DevMessage:  "Refresh token expired per expires_at.",
+		},
+		codes.REFRESH_TOKEN_REUSED: {
+			UserMessage: "Session is no longer valid. Please sign in again.",
+			DevMessage:  "Rotated refresh token replayed: token family revoked.",
+		},
+		codes.TOKEN_REVOKED: {
+			UserMessage: "Session has ended. Please sign in again.",
+			DevMessage:  "JWT revoked: jti or user found in revocation store.",
+		},
+		codes.EMAIL_NOT_VERIFIED: {
+			UserMessage: "Please verify your email address before signing in.",
+			DevMessage:  "Login blocked: user.activated_at is null and REQUIRE_EMAIL_VERIFICATION is set.",
+		},
+		codes.INVALID_RESET_TOKEN: {
+			UserMessage: "This password reset link is invalid or has expired. Please request a new one.",
+			DevMessage:  "Password reset token not found, already used or expired.",
+		},
+		codes.INVALID_MFA_CODE: {
+			UserMessage: "Invalid verification code. Please try again.",
+			DevMessage:  "TOTP or recovery code mismatch, or TOTP step already used.",
+		},
+		codes.MFA_ALREADY_ENABLED: {
+			UserMessage: "Two-factor authentication is already enabled.",
+			DevMessage:  "Enrollment attempted while user_mfa.confirmed_at is set.",
+		},
+		codes.MFA_NOT_ENABLED: {
+			UserMessage: "Two-factor authentication is not enabled.",
+			DevMessage:  "No confirmed user_mfa row for user.",
+		},
+		codes.MFA_REQUIRED_BY_ROLE: {
+			UserMessage: "Two-factor authentication is mandatory for your role and cannot be disabled.",
+			DevMessage:  "Disable blocked: a role of the user requires MFA.",
+		},
+		codes.TOO_MANY_LOGIN_ATTEMPTS: {
+			UserMessage: "Too many failed sign in attempts. Please wait before trying again.",
+			DevMessage:  "Login throttled: account locked or client IP over failed attempt limit, see Retry-After.",
+		},
+		codes.UNKNOWN_OIDC_PROVIDER: {
+			UserMessage: "This sign in provider is not available.",
+			DevMessage:  "Provider name not listed in OIDC_PROVIDERS.",
+		},
+		codes.INVALID_OAUTH_STATE: {
+			UserMessage: "Your sign in attempt has expired. Please try again.",
+			DevMessage:  "OAuth state not found, already used, expired or issued for another provider.",
+		},
+		codes.OIDC_LOGIN_FAILED: {
+			UserMessage: "Sign in with this provider failed. Please try again.",
+			DevMessage:  "Discovery, code exchange or ID token verification failed.",
+		},
+		codes.OIDC_EMAIL_NOT_VERIFIED: {
+			UserMessage: "Your email address is not verified with this provider.",
+			DevMessage:  "ID token has no email or email_verified is false, account not linked.",
+		},
+		codes.INVALID_API_KEY: {
+			UserMessage: "Invalid API key.",
+			DevMessage:  "X-API-Key not found, revoked or expired.",
+		},
+		codes.API_KEY_IP_NOT_ALLOWED: {
+			UserMessage: "This API key cannot be used from your network.",
+			DevMessage:  "Client IP not in the allowed_ips of the API key.",
+		},
+		codes.PASSWORD_REUSED: {
+			UserMessage: "Choose a password you have not used recently.",
+			DevMessage:  "New password matches the current password or one in the password history.",
+		},
+		codes.CANNOT_IMPERSONATE: {
+			UserMessage: "You cannot sign in as this user.",
+			DevMessage:  "Impersonation refused: target is the caller, or the caller is already impersonating.",
+		},
+		codes.ACCOUNT_SUSPENDED: {
+			UserMessage: "Your account has been suspended. Please contact support.",
+			DevMessage:  "User is suspended: suspended_at set and suspended_until not reached.",
+		},
+		codes.NOT_ALLOWED_WHILE_IMPERSONATING: {
+			UserMessage: "This action is not available while signed in as another user.",
+			DevMessage:  "Credential change attempted with an impersonation token (act claim present).",
+		},
+		codes.RATE_LIMITED: {
+			UserMessage: "Too many requests. Please slow down and try again later.",
+			DevMessage:  "Rate limit policy of the route group used up, see RateLimit-* and Retry-After headers.",
+		},
 	},
 	entities.PERMISSIONS: {
 		http.StatusNotFound: {
@@ -114,7 +214,45 @@ var errorRegistry = map[string]map[int]Message{
 		},
 		codes.ROLE_IN_USE: {
 			UserMessage: "Role is in use and cannot be modified.",
-			DevMessage:  "Role operation blocked: role assigned to active users",
+			DevMessage:  "Role operation blocked: role assigned to active users or inherited by other roles",
+		},
+		codes.ROLE_INHERITANCE_CYCLE: {
+			UserMessage: "A role cannot inherit from itself.",
+			DevMessage:  "Parent role is the role itself or one of the roles inheriting from it.",
+		},
+	},
+	entities.API_KEY: {
+		http.StatusNotFound: {
+			UserMessage: "API key not found.",
+			DevMessage:  "API key ID not found or already revoked.",
+		},
+		http.StatusBadRequest: {
+			UserMessage: "Invalid API key request.",
+			DevMessage:  "Unknown permission or expiry not in the future.",
+		},
+		http.StatusForbidden: {
+			UserMessage: "You cannot grant permissions you do not have.",
+			DevMessage:  "Requested API key permission not held by the caller.",
+		},
+	},
+	entities.SESSION: {
+		http.StatusNotFound: {
+			UserMessage: "Session not found.",
+			DevMessage:  "Session ID not found, revoked or owned by another user.",
+		},
+	},
+	entities.PRIVACY: {
+		http.StatusNotFound: {
+			UserMessage: "Data export or erasure request not found.",
+			DevMessage:  "Export ID not found, owned by another user, not ready or expired; or no pending erasure.",
+		},
+		http.StatusConflict: {
+			UserMessage: "A request for this account is already in progress.",
+			DevMessage:  "Pending data export or erasure request already exists for user.",
+		},
+		http.StatusBadRequest: {
+			UserMessage: "Invalid privacy request.",
+			DevMessage:  "Privacy request validation failed.",
 		},
 	},
 }
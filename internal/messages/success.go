@@ -29,6 +29,86 @@ var successRegistry = map[string]map[int]Message{
 			UserMessage: "Login successful. Welcome back!",
 			DevMessage:  "User authenticated successfully, JWT token generated.",
 		},
+		codes.TOKEN_REFRESHED: {
+			UserMessage: "Session refreshed successfully.",
+			DevMessage:  "Refresh token rotated, new JWT token generated.",
+		},
+		codes.LOGOUT_SUCCESS: {
+			UserMessage: "Logged out successfully.",
+			DevMessage:  "Access token and refresh tokens revoked.",
+		},
+		codes.EMAIL_VERIFIED: {
+			UserMessage: "Email verified successfully.",
+			DevMessage:  "User activated_at set from verification token.",
+		},
+		codes.VERIFICATION_SENT: {
+			UserMessage: "If the account exists and is not verified, a verification email has been sent.",
+			DevMessage:  "Verification email dispatched or silently skipped.",
+		},
+		codes.PASSWORD_RESET_SENT: {
+			UserMessage: "If an account exists for this email, a password reset link has been sent.",
+			DevMessage:  "Password reset email dispatched or silently skipped.",
+		},
+		codes.PASSWORD_RESET: {
+			UserMessage: "Your password has been reset. Please sign in with your new password.",
+			DevMessage:  "Password hash updated from reset token, sessions revoked.",
+		},
+		codes.MFA_REQUIRED: {
+			UserMessage: "Enter the code from your authenticator app to continue.",
+			DevMessage:  "Password verified, mfa_pending token issued.",
+		},
+		codes.MFA_ENROLLMENT_STARTED: {
+			UserMessage: "Scan the QR code with your authenticator app, then confirm with a code.",
+			DevMessage:  "Unconfirmed TOTP secret stored for user.",
+		},
+		codes.MFA_ENABLED: {
+			UserMessage: "Two-factor authentication enabled. Store your recovery codes somewhere safe.",
+			DevMessage:  "TOTP secret confirmed, recovery codes generated.",
+		},
+		codes.MFA_DISABLED: {
+			UserMessage: "Two-factor authentication disabled.",
+			DevMessage:  "TOTP secret and recovery codes deleted.",
+		},
+		codes.ACCOUNT_UNLOCKED: {
+			UserMessage: "Account unlocked.",
+			DevMessage:  "Failed login counter and locked_until cleared for user.",
+		},
+		codes.IMPERSONATION_STARTED: {
+			UserMessage: "You are now signed in as this user.",
+			DevMessage:  "Impersonation token issued and impersonation logged.",
+		},
+		codes.USER_SUSPENDED: {
+			UserMessage: "User suspended.",
+			DevMessage:  "Suspension stored, sessions revoked and history recorded.",
+		},
+		codes.USER_UNSUSPENDED: {
+			UserMessage: "User suspension lifted.",
+			DevMessage:  "Suspension cleared and history recorded.",
+		},
+		codes.PROFILE_UPDATED: {
+			UserMessage: "Profile updated successfully.",
+			DevMessage:  "Profile fields of the current user updated.",
+		},
+		codes.PASSWORD_CHANGED: {
+			UserMessage: "Your password has been changed. Please sign in with your new password.",
+			DevMessage:  "Password hash updated after current password check, sessions revoked.",
+		},
+		codes.EMAIL_CHANGE_REQUESTED: {
+			UserMessage: "Check your new email address for a link to confirm the change.",
+			DevMessage:  "Pending email stored and verification link sent to it.",
+		},
+		codes.USER_CREATED: {
+			UserMessage: "User created. Share the temporary password with them, it will not be shown again.",
+			DevMessage:  "User persisted with a temporary password and must_change_password set.",
+		},
+		codes.ROLE_ASSIGNED: {
+			UserMessage: "Role assigned to user.",
+			DevMessage:  "user_roles row added and cached permissions of the user dropped.",
+		},
+		codes.ROLE_REMOVED: {
+			UserMessage: "Role removed from user.",
+			DevMessage:  "user_roles row deleted and cached permissions of the user dropped.",
+		},
 	},
 	entities.PRODUCT: {
 		http.StatusCreated: {
@@ -120,6 +200,52 @@ var successRegistry = map[string]map[int]Message{
 			DevMessage:  "Role entity deleted from database.",
 		},
 	},
+	entities.API_KEY: {
+		http.StatusCreated: {
+			UserMessage: "API key created. Copy it now, it will not be shown again.",
+			DevMessage:  "API key hash persisted, plain key returned once.",
+		},
+		http.StatusOK: {
+			UserMessage: "API keys retrieved successfully.",
+			DevMessage:  "API key entities retrieved from DB.",
+		},
+		http.StatusNoContent: {
+			UserMessage: "API key revoked successfully.",
+			DevMessage:  "API key revoked_at set.",
+		},
+	},
+	entities.SESSION: {
+		http.StatusOK: {
+			UserMessage: "Sessions retrieved successfully.",
+			DevMessage:  "Active session entities retrieved from DB.",
+		},
+		http.StatusNoContent: {
+			UserMessage: "Session signed out successfully.",
+			DevMessage:  "Session revoked and its refresh token family invalidated.",
+		},
+	},
+	entities.PRIVACY: {
+		http.StatusOK: {
+			UserMessage: "Data exports retrieved successfully.",
+			DevMessage:  "Data export entities retrieved from DB.",
+		},
+		codes.DATA_EXPORT_REQUESTED: {
+			UserMessage: "Your data export is being prepared. We will email you when it is ready to download.",
+			DevMessage:  "Pending data export stored and background job started.",
+		},
+		codes.ERASURE_SCHEDULED: {
+			UserMessage: "The account is scheduled for erasure. It can be cancelled until the scheduled date.",
+			DevMessage:  "Pending erasure request stored with the cooling-off date.",
+		},
+		codes.ERASURE_CANCELLED: {
+			UserMessage: "The account erasure has been cancelled.",
+			DevMessage:  "Pending erasure request marked cancelled.",
+		},
+		codes.USER_ERASED: {
+			UserMessage: "The account has been erased.",
+			DevMessage:  "User and customer PII anonymized, credentials deleted, orders and payments kept.",
+		},
+	},
 }
 
 func Success(entity string, status int) string {
@@ -0,0 +1,13 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// CodeChallenge derives the S256 PKCE challenge of a code verifier (RFC 7636).
+// Verifiers from utils.GenerateOpaqueToken are 43 URL-safe characters as the RFC requires.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
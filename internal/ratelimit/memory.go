@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	// token bucket
+	tokens  float64
+	updated time.Time
+
+	// sliding window
+	windowStart time.Time
+	current     int
+	previous    int
+
+	expiresAt time.Time
+}
+
+// MemoryStore keeps rate limit state in process memory. Every instance counts its
+// own requests, so the effective limit grows with the number of instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = &memoryEntry{tokens: float64(limit.Requests), updated: now, windowStart: now.Truncate(limit.Window)}
+		s.entries[key] = entry
+	}
+
+	if limit.Algorithm == SlidingWindow {
+		return entry.takeWindow(limit, now), nil
+	}
+	return entry.takeToken(limit, now), nil
+}
+
+func (e *memoryEntry) takeToken(limit Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	perSecond := capacity / limit.Window.Seconds()
+
+	if elapsed := now.Sub(e.updated).Seconds(); elapsed > 0 {
+		e.tokens = math.Min(capacity, e.tokens+elapsed*perSecond)
+	}
+	e.updated = now
+
+	result := Result{Limit: limit.Requests}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - e.tokens) / perSecond)
+	}
+
+	result.Remaining = int(math.Floor(e.tokens))
+	result.Reset = seconds((capacity - e.tokens) / perSecond)
+	e.expiresAt = now.Add(result.Reset)
+
+	return result
+}
+
+func (e *memoryEntry) takeWindow(limit Limit, now time.Time) Result {
+	window := limit.Window
+	capacity := float64(limit.Requests)
+
+	start := now.Truncate(window)
+	if !start.Equal(e.windowStart) {
+		if start.Sub(e.windowStart) == window {
+			e.previous = e.current
+		} else {
+			e.previous = 0
+		}
+		e.current = 0
+		e.windowStart = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - elapsed.Seconds()/window.Seconds()
+	estimate := float64(e.previous)*weight + float64(e.current)
+
+	result := Result{Limit: limit.Requests}
+	if estimate+1 <= capacity {
+		e.current++
+		estimate++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = e.windowRetryAfter(capacity, elapsed, window)
+	}
+
+	result.Remaining = int(math.Max(0, capacity-math.Ceil(estimate)))
+	result.Reset = window - elapsed
+	e.expiresAt = start.Add(2 * window)
+
+	return result
+}
+
+// windowRetryAfter is how long until the estimate leaves room for one more request.
+func (e *memoryEntry) windowRetryAfter(capacity float64, elapsed, window time.Duration) time.Duration {
+	current, previous := float64(e.current), float64(e.previous)
+
+	// The previous window fades out before the current one ends
+	if current <= capacity-1 && previous > 0 {
+		until := window.Seconds()*(1-(capacity-1-current)/previous) - elapsed.Seconds()
+		if until <= (window - elapsed).Seconds() {
+			return seconds(until)
+		}
+	}
+
+	// Otherwise the current window becomes the previous one and has to fade out
+	wait := (window - elapsed).Seconds()
+	if current > capacity-1 {
+		wait += window.Seconds() * (1 - (capacity-1)/current)
+	}
+	return seconds(wait)
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, entry := range s.entries {
+		if entry.expiresAt.Before(now) {
+			delete(s.entries, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// start is aligned to a minute so sliding windows begin on it.
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type step struct {
+	at            time.Duration
+	wantAllowed   bool
+	wantRemaining int
+	wantRetry     time.Duration
+}
+
+func runSteps(t *testing.T, limit Limit, steps []step) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	for i, s := range steps {
+		result, err := store.Take(context.Background(), "key", limit, start.Add(s.at))
+		if err != nil {
+			t.Fatalf("step %d: Take() error = %v", i, err)
+		}
+
+		if result.Allowed != s.wantAllowed {
+			t.Errorf("step %d at %s: Allowed = %v, want %v", i, s.at, result.Allowed, s.wantAllowed)
+		}
+		if result.Remaining != s.wantRemaining {
+			t.Errorf("step %d at %s: Remaining = %d, want %d", i, s.at, result.Remaining, s.wantRemaining)
+		}
+		if result.RetryAfter != s.wantRetry {
+			t.Errorf("step %d at %s: RetryAfter = %s, want %s", i, s.at, result.RetryAfter, s.wantRetry)
+		}
+		if result.Limit != limit.Requests {
+			t.Errorf("step %d at %s: Limit = %d, want %d", i, s.at, result.Limit, limit.Requests)
+		}
+	}
+}
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	limit := Limit{Algorithm: TokenBucket, Requests: 3, Window: time.Minute}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst up to the limit",
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 2},
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				{at: 0, wantAllowed: false, wantRemaining: 0, wantRetry: 20 * time.Second},
+			},
+		},
+		{
+			name: "refills one token per window share",
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 2},
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				{at: 10 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: 10 * time.Second},
+				{at: 20 * time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name: "refill is capped at the limit",
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 2},
+				{at: time.Hour, wantAllowed: true, wantRemaining: 2},
+				{at: time.Hour, wantAllowed: true, wantRemaining: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, limit, tt.steps)
+		})
+	}
+}
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	limit := Limit{Algorithm: SlidingWindow, Requests: 2, Window: time.Minute}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "limit within one window",
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 10 * time.Second, wantAllowed: true, wantRemaining: 0},
+				{at: 20 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: 70 * time.Second},
+			},
+		},
+		{
+			name: "previous window is weighted by its overlap",
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				// 2 * 0.75 = 1.5 leaves no room for a whole request
+				{at: 75 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: 15 * time.Second},
+				// 2 * 0.5 = 1 leaves room for one
+				{at: 90 * time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name: "windows further back are forgotten",
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 0, wantAllowed: true, wantRemaining: 0},
+				{at: 125 * time.Second, wantAllowed: true, wantRemaining: 1},
+				{at: 125 * time.Second, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, limit, tt.steps)
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Algorithm: TokenBucket, Requests: 1, Window: time.Minute}
+
+	for _, key := range []string{"ip:203.0.113.1", "ip:203.0.113.2"} {
+		result, err := store.Take(context.Background(), key, limit, start)
+		if err != nil {
+			t.Fatalf("Take(%s) error = %v", key, err)
+		}
+		if !result.Allowed {
+			t.Errorf("Take(%s) Allowed = false, want true", key)
+		}
+	}
+}
+
+func TestMemoryStorePurge(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      Limit
+		purgeAfter time.Duration
+		wantPurged int64
+	}{
+		{name: "token bucket still refilling", limit: Limit{Algorithm: TokenBucket, Requests: 2, Window: time.Minute}, purgeAfter: 10 * time.Second, wantPurged: 0},
+		{name: "token bucket refilled", limit: Limit{Algorithm: TokenBucket, Requests: 2, Window: time.Minute}, purgeAfter: 31 * time.Second, wantPurged: 1},
+		{name: "sliding window still weighted", limit: Limit{Algorithm: SlidingWindow, Requests: 2, Window: time.Minute}, purgeAfter: 90 * time.Second, wantPurged: 0},
+		{name: "sliding window faded out", limit: Limit{Algorithm: SlidingWindow, Requests: 2, Window: time.Minute}, purgeAfter: 121 * time.Second, wantPurged: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			if _, err := store.Take(context.Background(), "key", tt.limit, start); err != nil {
+				t.Fatalf("Take() error = %v", err)
+			}
+
+			purged, err := store.Purge(context.Background(), start.Add(tt.purgeAfter))
+			if err != nil {
+				t.Fatalf("Purge() error = %v", err)
+			}
+			if purged != tt.wantPurged {
+				t.Errorf("Purge() = %d, want %d", purged, tt.wantPurged)
+			}
+		})
+	}
+}
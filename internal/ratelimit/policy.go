@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+)
+
+// KeyFunc returns the identity a request is counted against.
+type KeyFunc func(r *http.Request) string
+
+// Policy is the limit of one route group. Every group counts requests separately.
+type Policy struct {
+	Name     string
+	Limit    Limit
+	Key      KeyFunc
+	Disabled bool
+}
+
+// KeyByIP counts requests per client IP.
+func KeyByIP(r *http.Request) string {
+	ip, _ := r.Context().Value(constants.CLIENT_IP_KEY).(string)
+	return "ip:" + ip
+}
+
+// KeyByUser counts requests per signed in user. API keys are counted per key and
+// anonymous requests per client IP.
+func KeyByUser(r *http.Request) string {
+	if userID, ok := r.Context().Value(constants.USER_ID_KEY).(string); ok && userID != "" {
+		return "user:" + userID
+	}
+	return KeyByAPIKey(r)
+}
+
+// KeyByAPIKey counts requests per API key, and other requests per client IP.
+func KeyByAPIKey(r *http.Request) string {
+	if keyID, ok := r.Context().Value(constants.API_KEY_ID_KEY).(string); ok && keyID != "" {
+		return "api_key:" + keyID
+	}
+	return KeyByIP(r)
+}
+
+var keyFuncs = map[string]KeyFunc{
+	"ip":      KeyByIP,
+	"user":    KeyByUser,
+	"api_key": KeyByAPIKey,
+}
+
+// PolicyFromEnv reads the policy of a route group, falling back to def:
+//
+//	RATE_LIMIT_<NAME>=20/1m            requests per window, or "off"
+//	RATE_LIMIT_<NAME>_ALGORITHM=token_bucket | sliding_window
+//	RATE_LIMIT_<NAME>_KEY=ip | user | api_key
+//
+// RATE_LIMIT_ENABLED=false turns every policy off.
+func PolicyFromEnv(name string, def Policy) Policy {
+	policy := def
+	policy.Name = name
+
+	prefix := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+
+	if raw := env.GetStringEnv(prefix, ""); raw != "" {
+		if strings.EqualFold(raw, "off") {
+			policy.Disabled = true
+		} else if requests, window, err := ParseRate(raw); err != nil {
+			log.Printf("⚠️ Ignoring %s: %v", prefix, err)
+		} else {
+			policy.Limit.Requests, policy.Limit.Window = requests, window
+		}
+	}
+
+	switch algorithm := Algorithm(env.GetStringEnv(prefix+"_ALGORITHM", string(policy.Limit.Algorithm))); algorithm {
+	case TokenBucket, SlidingWindow:
+		policy.Limit.Algorithm = algorithm
+	default:
+		log.Printf("⚠️ Ignoring %s_ALGORITHM: unknown algorithm %q", prefix, algorithm)
+	}
+
+	if raw := env.GetStringEnv(prefix+"_KEY", ""); raw != "" {
+		if key, ok := keyFuncs[raw]; ok {
+			policy.Key = key
+		} else {
+			log.Printf("⚠️ Ignoring %s_KEY: unknown key %q", prefix, raw)
+		}
+	}
+
+	if !env.GetBoolEnv("RATE_LIMIT_ENABLED", true) {
+		policy.Disabled = true
+	}
+
+	return policy
+}
+
+// ParseRate parses a rate such as "100/1m" or "5/15m". The window defaults to one
+// unit when only a unit is given, so "60/m" is 60 per minute.
+func ParseRate(raw string) (int, time.Duration, error) {
+	count, per, ok := strings.Cut(strings.TrimSpace(raw), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("rate %q is not requests/window", raw)
+	}
+
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return 0, 0, fmt.Errorf("rate %q needs a positive request count", raw)
+	}
+
+	if per != "" && !strings.ContainsAny(per[:1], "0123456789") {
+		per = "1" + per
+	}
+	window, err := time.ParseDuration(per)
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("rate %q needs a positive window", raw)
+	}
+
+	return requests, window, nil
+}
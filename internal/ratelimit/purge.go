@@ -0,0 +1,27 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+)
+
+// StartPurger removes idle keys from store every interval until ctx is cancelled.
+func StartPurger(ctx context.Context, store Store, interval time.Duration, log *logger.AppLogger) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := store.Purge(ctx, now); err != nil {
+					log.ErrLogger.ErrorContext(ctx, "failed to purge rate limit state", "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Algorithm selects how requests are counted against a Limit.
+type Algorithm string
+
+const (
+	// TokenBucket allows bursts up to Requests and refills Requests tokens every Window.
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingWindow allows Requests per Window, weighting the previous window by how
+	// much of it still overlaps the last Window.
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+// Limit is the number of requests allowed per window.
+type Limit struct {
+	Algorithm Algorithm
+	Requests  int
+	Window    time.Duration
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s %s", l.Requests, l.Window, l.Algorithm)
+}
+
+// Result is the outcome of taking one request from a limit.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the limit is fully available again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the state of every rate limited key. The algorithm runs inside the
+// store so a shared backend can apply it atomically for all instances.
+type Store interface {
+	// Take counts one request for key against limit at now.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+
+	// Purge removes the state of keys idle long enough to be back at their full
+	// limit and returns how many were removed.
+	Purge(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,60 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore keeps revocations in process memory. It is meant for tests and
+// single instance development setups, entries are lost on restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Revoke(ctx context.Context, key string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{revokedAt: time.Now(), expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) Lookup(ctx context.Context, keys ...string) (map[string]time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revoked := make(map[string]time.Time)
+	for _, key := range keys {
+		if entry, ok := s.entries[key]; ok {
+			revoked[key] = entry.revokedAt
+		}
+	}
+
+	return revoked, nil
+}
+
+func (s *MemoryStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, entry := range s.entries {
+		if entry.expiresAt.Before(now) {
+			delete(s.entries, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,54 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresStore persists revocations in the revoked_tokens table so they are
+// shared between instances and survive restarts.
+type PostgresStore struct {
+	DB *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+func (s *PostgresStore) Revoke(ctx context.Context, key string, expiresAt time.Time) error {
+	entry := models.RevokedToken{
+		Key:       key,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	return s.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"revoked_at", "expires_at"}),
+		}).
+		Create(&entry).Error
+}
+
+func (s *PostgresStore) Lookup(ctx context.Context, keys ...string) (map[string]time.Time, error) {
+	var entries []models.RevokedToken
+	if err := s.DB.WithContext(ctx).Where("key IN ?", keys).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		revoked[entry.Key] = entry.RevokedAt
+	}
+
+	return revoked, nil
+}
+
+func (s *PostgresStore) Purge(ctx context.Context, now time.Time) (int64, error) {
+	result := s.DB.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,32 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/logger"
+)
+
+// StartPurger removes expired entries from store every interval until ctx is cancelled.
+func StartPurger(ctx context.Context, store Store, interval time.Duration, log *logger.AppLogger) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				purged, err := store.Purge(ctx, now)
+				if err != nil {
+					log.ErrLogger.ErrorContext(ctx, "failed to purge revoked tokens", "error", err)
+					continue
+				}
+				if purged > 0 {
+					log.InfoLogger.InfoContext(ctx, "purged expired revoked tokens", "count", purged)
+				}
+			}
+		}
+	}()
+}
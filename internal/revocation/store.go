@@ -0,0 +1,35 @@
+package revocation
+
+import (
+	"context"
+	"time"
+)
+
+// Store records revoked tokens until they would have expired on their own.
+// Keys are namespaced strings built with TokenKey, SessionKey and UserKey.
+type Store interface {
+	// Revoke marks key as revoked now. The entry can be purged after expiresAt.
+	// Revoking an existing key refreshes its revocation time.
+	Revoke(ctx context.Context, key string, expiresAt time.Time) error
+
+	// Lookup returns the revocation time of every key that is currently revoked.
+	Lookup(ctx context.Context, keys ...string) (map[string]time.Time, error)
+
+	// Purge removes entries that expired before now and returns how many were removed.
+	Purge(ctx context.Context, now time.Time) (int64, error)
+}
+
+// TokenKey identifies a single access token by its jti claim.
+func TokenKey(jti string) string {
+	return "jti:" + jti
+}
+
+// SessionKey identifies every access token issued for a session by its sid claim.
+func SessionKey(sessionID string) string {
+	return "sid:" + sessionID
+}
+
+// UserKey identifies every access token of a user issued before the revocation time.
+func UserKey(userID string) string {
+	return "user:" + userID
+}
@@ -19,15 +19,11 @@ func SendResponse(w http.ResponseWriter, data *models.Response) error {
 }
 
 func GenSuccessResponse(entity string, messageCode int, data interface{}) *models.Response {
-	httpCode := messageCode
-	if httpCode == http.StatusNoContent || httpCode == http.StatusAccepted || httpCode == codes.LOGIN_SUCCESS {
+	httpCode := codes.HTTPStatus(messageCode)
+	if httpCode == http.StatusNoContent || httpCode == http.StatusAccepted {
 		httpCode = http.StatusOK
 	}
 
-	if httpCode == codes.ROLE_IN_USE {
-		httpCode = http.StatusConflict
-	}
-
 	return &models.Response{
 		Success: true,
 		Message: messages.Success(entity, messageCode),
@@ -41,7 +37,7 @@ func GenErrorResponse(entity string, statusCode int, err error) *models.Response
 	return &models.Response{
 		Success: false,
 		Message: appErr.UserMessage,
-		Code:    statusCode,
+		Code:    codes.HTTPStatus(statusCode),
 	}
 }
 
@@ -7,72 +7,208 @@ import (
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
 	appErrors "github.com/Aboagye-Dacosta/shopBackend/internal/errors"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/keyring"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
-	UserID      string   `json:"user_id"`
-	Roles       []string `json:"roles"`
-	Permissions []string `json:"permissions"`
+	UserID    string   `json:"user_id"`
+	SessionID string   `json:"sid,omitempty"`
+	Version   int      `json:"ver"`
+	Roles     []string `json:"roles"`
+	Actor     *Actor   `json:"act,omitempty"`
+
+	// Permissions are resolved from the roles of the user on every request, never read from the token
+	Permissions []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userId string, roles []models.Role) (string, error) {
+// Actor identifies the user acting on behalf of the subject of an impersonation token (RFC 8693).
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// ActionClaims are carried by single purpose tokens such as email verification links.
+// They are never accepted as access tokens.
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	Email   string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+const (
+	PurposeVerifyEmail = "verify_email"
+	PurposeMFA         = "mfa_pending"
+)
+
+// Every kind of token names itself in the typ header and the aud claim, and is only
+// accepted where that kind is expected, so one can never stand in for another.
+const (
+	accessTokenType = "at+jwt"
+	actionTokenType = "action+jwt"
+	accessAudience  = "access"
+)
+
+// AccessTokenTTL is how long an access token stays valid.
+func AccessTokenTTL() time.Duration {
+	return env.GetDurationEnv("ACCESS_TOKEN_TTL", time.Hour)
+}
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for a new access token.
+func RefreshTokenTTL() time.Duration {
+	return env.GetDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// GenerateJWT signs an access token for the user. sessionId ties the token to the
+// refresh token family it was issued with so logging out can revoke both, and
+// tokenVersion to the token version of the user at the time.
+func GenerateJWT(userId, sessionId string, tokenVersion int, roles []models.Role) (string, error) {
 
 	// Define the JWT claims
 	claims := Claims{
-		UserID:      userId,
-		Roles:       getRoles(roles),
-		Permissions: getPermissions(roles),
+		UserID:    userId,
+		SessionID: sessionId,
+		Version:   tokenVersion,
+		Roles:     getRoles(roles),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Audience:  jwt.ClaimStrings{accessAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return signToken(claims, accessTokenType)
+}
+
+// GenerateImpersonationJWT signs a short lived access token for userId that names
+// actorId as the actor. It is tied to impersonationId so it can be revoked like a session.
+func GenerateImpersonationJWT(userId, actorId, impersonationId string, tokenVersion int, ttl time.Duration) (string, error) {
+	claims := Claims{
+		UserID:    userId,
+		SessionID: impersonationId,
+		Version:   tokenVersion,
+		Roles:     make([]string, 0),
+		Actor:     &Actor{Subject: actorId},
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)),
+			ID:        uuid.NewString(),
+			Audience:  jwt.ClaimStrings{accessAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	// Create a new JWT token with the claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(env.GetStringEnv("JWT_SECRETE", "klwelwkewlek")))
+	return signToken(claims, accessTokenType)
 }
 
-func VerifyJWT(tokenString string) (string, []string, error) {
+func VerifyJWT(tokenString string) (*Claims, error) {
 	if tokenString == "" {
-		return "", nil, appErrors.NoTokenProvided(nil)
+		return nil, appErrors.NoTokenProvided(nil)
 	}
 
 	claims := &Claims{}
+	if err := parseToken(tokenString, claims, accessTokenType, accessAudience); err != nil {
+		return nil, err
+	}
+
+	if claims.UserID == "" {
+		return nil, appErrors.InvalidToken(stdErrors.New("not an access token"))
+	}
+
+	return claims, nil
+}
+
+// GenerateActionToken signs a token that can only be used for purpose.
+func GenerateActionToken(purpose, userId, email string, ttl time.Duration) (string, error) {
+	claims := ActionClaims{
+		Purpose: purpose,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userId,
+			Audience:  jwt.ClaimStrings{purpose},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return signToken(claims, actionTokenType)
+}
+
+// VerifyActionToken checks the signature and expiry of an action token and that it was issued for purpose.
+func VerifyActionToken(purpose, tokenString string) (*ActionClaims, error) {
+	if tokenString == "" {
+		return nil, appErrors.NoTokenProvided(nil)
+	}
+
+	claims := &ActionClaims{}
+	if err := parseToken(tokenString, claims, actionTokenType, purpose); err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != purpose || claims.Subject == "" {
+		return nil, appErrors.InvalidToken(stdErrors.New("token issued for another purpose"))
+	}
+
+	return claims, nil
+}
+
+// signToken signs claims with the active key of the keyring and names it in the kid header.
+// typ names the kind of token in the typ header.
+func signToken(claims jwt.Claims, typ string) (string, error) {
+	keys := keyring.Default()
+	if keys == nil {
+		return "", stdErrors.New("signing keyring not configured")
+	}
+
+	key := keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	token.Header["typ"] = typ
+
+	return token.SignedString(key.Private)
+}
+
+// parseToken verifies the signature of tokenString with the key named by its kid header,
+// and that it is a token of kind typ issued for audience.
+// Keys stay in the ring after a rotation so tokens they signed remain valid until expiry.
+func parseToken(tokenString string, claims jwt.Claims, typ, audience string) error {
+	keys := keyring.Default()
+	if keys == nil {
+		return appErrors.InvalidToken(stdErrors.New("signing keyring not configured"))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if header, _ := token.Header["typ"].(string); header != typ {
+			return nil, appErrors.InvalidToken(stdErrors.New("unexpected token type"))
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.Lookup(kid)
+		if !ok {
+			return nil, appErrors.InvalidToken(stdErrors.New("unknown signing key"))
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, appErrors.InvalidToken(stdErrors.New("unexpected signing method"))
 		}
-		return []byte(env.GetStringEnv("JWT_SECRETE", "klwelwkewlek")), nil
-	})
+		return key.Public, nil
+	}, jwt.WithValidMethods(keys.Methods()), jwt.WithAudience(audience))
 
 	if err != nil {
 		if stdErrors.Is(err, jwt.ErrTokenExpired) {
-			return "", nil, appErrors.ExpiredToken(err)
+			return appErrors.ExpiredToken(err)
 		}
-		return "", nil, appErrors.InvalidToken(err)
+		return appErrors.InvalidToken(err)
 	}
 
 	if !token.Valid {
-		return "", nil, appErrors.InvalidToken(nil)
+		return appErrors.InvalidToken(nil)
 	}
 
-	return claims.UserID, claims.Permissions, nil
+	return nil
 }
 
-func getPermissions(roles []models.Role) []string {
-	var permissions = make([]string, 0)
-
-	for _, role := range roles {
-		for _, perm := range role.Permissions {
-			permissions = append(permissions, perm.Name)
-		}
-	}
-
-	return permissions
-}
 func getRoles(roles []models.Role) []string {
 	var values = make([]string, 0)
 
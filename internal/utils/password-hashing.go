@@ -1,10 +1,144 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrPasswordMismatch    = errors.New("password does not match hash")
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+// PasswordHasher is one password hashing scheme. Hashes are self describing so the
+// scheme and parameters that produced a stored hash can always be recovered from it.
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password using the current parameters.
+	Hash(password string) (string, error)
+	// Verify compares password with an encoded hash produced by this scheme.
+	Verify(password, hashed string) error
+	// Handles reports whether hashed was produced by this scheme.
+	Handles(hashed string) bool
+	// Outdated reports whether hashed was produced with other parameters than the current ones.
+	Outdated(hashed string) bool
+}
+
+// Argon2idParams are the cost parameters of argon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
 
+// Argon2idParamsFromEnv reads the argon2id parameters. The defaults follow the OWASP recommendation.
+func Argon2idParamsFromEnv() Argon2idParams {
+	return Argon2idParams{
+		Memory:      uint32(env.GetIntEnv("ARGON2_MEMORY_KIB", 64*1024)),
+		Iterations:  uint32(env.GetIntEnv("ARGON2_ITERATIONS", 3)),
+		Parallelism: uint8(env.GetIntEnv("ARGON2_PARALLELISM", 2)),
+		SaltLength:  uint32(env.GetIntEnv("ARGON2_SALT_LENGTH", 16)),
+		KeyLength:   uint32(env.GetIntEnv("ARGON2_KEY_LENGTH", 32)),
+	}
+}
+
+// Argon2idHasher encodes hashes in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Params.Iterations, h.Params.Memory, h.Params.Parallelism, h.Params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Params.Memory, h.Params.Iterations, h.Params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) Verify(password, hashed string) error {
+	params, salt, key, err := decodeArgon2id(hashed)
+	if err != nil {
+		return err
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return ErrPasswordMismatch
+	}
+
+	return nil
+}
+
+func (h Argon2idHasher) Handles(hashed string) bool {
+	return strings.HasPrefix(hashed, "$argon2id$")
+}
+
+func (h Argon2idHasher) Outdated(hashed string) bool {
+	params, _, _, err := decodeArgon2id(hashed)
+	if err != nil {
+		return true
+	}
+
+	return params != h.Params
+}
+
+func decodeArgon2id(hashed string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// BcryptHasher verifies the hashes created before argon2id was introduced.
+// It can still be selected to create hashes with PASSWORD_HASH_ALGORITHM=bcrypt.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 	if err != nil {
 		return "", err
 	}
@@ -12,6 +146,67 @@ func HashPassword(password string) (string, error) {
 	return string(hash), nil
 }
 
+func (h BcryptHasher) Verify(password, hashed string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (h BcryptHasher) Handles(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+func (h BcryptHasher) Outdated(hashed string) bool {
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != h.Cost
+}
+
+// currentHasher returns the scheme new hashes are created with, selected by PASSWORD_HASH_ALGORITHM.
+func currentHasher() PasswordHasher {
+	if env.GetStringEnv("PASSWORD_HASH_ALGORITHM", "argon2id") == "bcrypt" {
+		return bcryptHasher()
+	}
+	return Argon2idHasher{Params: Argon2idParamsFromEnv()}
+}
+
+func bcryptHasher() BcryptHasher {
+	return BcryptHasher{Cost: env.GetIntEnv("BCRYPT_COST", bcrypt.DefaultCost)}
+}
+
+// hasherFor returns the scheme that produced hashed.
+func hasherFor(hashed string) (PasswordHasher, error) {
+	for _, h := range []PasswordHasher{Argon2idHasher{Params: Argon2idParamsFromEnv()}, bcryptHasher()} {
+		if h.Handles(hashed) {
+			return h, nil
+		}
+	}
+
+	return nil, ErrUnknownPasswordHash
+}
+
+// HashPassword hashes password with the configured scheme and parameters.
+func HashPassword(password string) (string, error) {
+	return currentHasher().Hash(password)
+}
+
+// VerifyWithHashed compares password with a hash of any supported scheme.
 func VerifyWithHashed(password, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	h, err := hasherFor(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	return h.Verify(password, hashedPassword)
+}
+
+// NeedsRehash reports whether hashedPassword should be replaced because it was made
+// with another scheme or other parameters than the configured ones.
+func NeedsRehash(hashedPassword string) bool {
+	current := currentHasher()
+	return !current.Handles(hashedPassword) || current.Outdated(hashedPassword)
 }
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams keeps the tests fast, the production defaults take far longer.
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func useTestArgon2id(t *testing.T) {
+	t.Helper()
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "argon2id")
+	t.Setenv("ARGON2_MEMORY_KIB", "1024")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+	t.Setenv("ARGON2_SALT_LENGTH", "16")
+	t.Setenv("ARGON2_KEY_LENGTH", "32")
+}
+
+func TestPasswordHashers(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+		prefix string
+	}{
+		{name: "argon2id", hasher: Argon2idHasher{Params: testArgon2idParams}, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+		{name: "bcrypt", hasher: BcryptHasher{Cost: bcrypt.MinCost}, prefix: "$2a$04$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashed, err := tt.hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+
+			if !strings.HasPrefix(hashed, tt.prefix) {
+				t.Errorf("Hash() = %q, want prefix %q", hashed, tt.prefix)
+			}
+			if !tt.hasher.Handles(hashed) {
+				t.Errorf("Handles(%q) = false, want true", hashed)
+			}
+			if tt.hasher.Outdated(hashed) {
+				t.Errorf("Outdated(%q) = true, want false", hashed)
+			}
+
+			if err := tt.hasher.Verify("correct horse battery staple", hashed); err != nil {
+				t.Errorf("Verify() with the right password error = %v", err)
+			}
+			if err := tt.hasher.Verify("wrong password", hashed); !errors.Is(err, ErrPasswordMismatch) {
+				t.Errorf("Verify() with a wrong password error = %v, want %v", err, ErrPasswordMismatch)
+			}
+
+			again, err := tt.hasher.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+			if again == hashed {
+				t.Error("Hash() returned the same hash twice, want a fresh salt")
+			}
+		})
+	}
+}
+
+func TestArgon2idOutdated(t *testing.T) {
+	hasher := Argon2idHasher{Params: testArgon2idParams}
+
+	tests := []struct {
+		name   string
+		params Argon2idParams
+		want   bool
+	}{
+		{name: "same parameters", params: testArgon2idParams, want: false},
+		{name: "more memory", params: Argon2idParams{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, want: true},
+		{name: "more iterations", params: Argon2idParams{Memory: 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}, want: true},
+		{name: "longer key", params: Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 64}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashed, err := Argon2idHasher{Params: tt.params}.Hash("password")
+			if err != nil {
+				t.Fatalf("Hash() error = %v", err)
+			}
+
+			if got := hasher.Outdated(hashed); got != tt.want {
+				t.Errorf("Outdated() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeArgon2id(t *testing.T) {
+	tests := []struct {
+		name    string
+		hashed  string
+		want    Argon2idParams
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			hashed: "$argon2id$v=19$m=65536,t=3,p=2$c29tZXNhbHRzb21lc2FsdA$aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g",
+			want:   Argon2idParams{Memory: 65536, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32},
+		},
+		{name: "other algorithm", hashed: "$argon2i$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA", wantErr: true},
+		{name: "other version", hashed: "$argon2id$v=16$m=65536,t=3,p=2$c2FsdA$aGFzaA", wantErr: true},
+		{name: "missing parameters", hashed: "$argon2id$v=19$m=65536$c2FsdA$aGFzaA", wantErr: true},
+		{name: "bad salt encoding", hashed: "$argon2id$v=19$m=65536,t=3,p=2$!!!$aGFzaA", wantErr: true},
+		{name: "bad hash encoding", hashed: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$!!!", wantErr: true},
+		{name: "too few fields", hashed: "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA", wantErr: true},
+		{name: "bcrypt hash", hashed: "$2a$10$abcdefghijklmnopqrstuv", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _, _, err := decodeArgon2id(tt.hashed)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownPasswordHash) {
+					t.Errorf("decodeArgon2id() error = %v, want %v", err, ErrUnknownPasswordHash)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeArgon2id() error = %v", err)
+			}
+			if params != tt.want {
+				t.Errorf("decodeArgon2id() params = %+v, want %+v", params, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyWithHashed(t *testing.T) {
+	useTestArgon2id(t)
+
+	argon, err := Argon2idHasher{Params: testArgon2idParams}.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	legacy, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hashed   string
+		wantErr  error
+	}{
+		{name: "argon2id match", password: "password", hashed: argon},
+		{name: "argon2id mismatch", password: "other", hashed: argon, wantErr: ErrPasswordMismatch},
+		{name: "bcrypt match", password: "password", hashed: legacy},
+		{name: "bcrypt mismatch", password: "other", hashed: legacy, wantErr: ErrPasswordMismatch},
+		{name: "unknown scheme", password: "password", hashed: "plain-text", wantErr: ErrUnknownPasswordHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyWithHashed(tt.password, tt.hashed); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWithHashed() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current, err := Argon2idHasher{Params: testArgon2idParams}.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	weaker, err := Argon2idHasher{Params: Argon2idParams{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	legacy, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		algorithm string
+		hashed    string
+		want      bool
+	}{
+		{name: "argon2id with current parameters", algorithm: "argon2id", hashed: current, want: false},
+		{name: "argon2id with old parameters", algorithm: "argon2id", hashed: weaker, want: true},
+		{name: "bcrypt while argon2id is current", algorithm: "argon2id", hashed: legacy, want: true},
+		{name: "bcrypt with current cost", algorithm: "bcrypt", hashed: legacy, want: false},
+		{name: "argon2id while bcrypt is current", algorithm: "bcrypt", hashed: current, want: true},
+		{name: "unknown scheme", algorithm: "argon2id", hashed: "plain-text", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestArgon2id(t)
+			t.Setenv("PASSWORD_HASH_ALGORITHM", tt.algorithm)
+			t.Setenv("BCRYPT_COST", "4")
+
+			if got := NeedsRehash(tt.hashed); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,39 +1,123 @@
 package utils
 
 import (
-    "errors"
-    "regexp"
+	"bufio"
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
 )
 
 var (
-    ErrTooShort       = errors.New("password must be at least 8 characters long")
-    ErrMissingUpper   = errors.New("password must contain at least one uppercase letter")
-    ErrMissingLower   = errors.New("password must contain at least one lowercase letter")
-    ErrMissingNumber  = errors.New("password must contain at least one number")
-    ErrMissingSpecial = errors.New("password must contain at least one special character")
+	upperPattern   = regexp.MustCompile(`[A-Z]`)
+	lowerPattern   = regexp.MustCompile(`[a-z]`)
+	numberPattern  = regexp.MustCompile(`[0-9]`)
+	specialPattern = regexp.MustCompile(`[!@#~$%^&*()+|_.,<>?/{}\-=\[\]:;'"` + "`" + `\\]`)
 )
 
-// ValidatePassword checks a password against common policy rules
-func ValidatePassword(password string) error {
-    if len(password) < 8 {
-        return ErrTooShort
-    }
-
-    upper := regexp.MustCompile(`[A-Z]`)
-    lower := regexp.MustCompile(`[a-z]`)
-    number := regexp.MustCompile(`[0-9]`)
-    special := regexp.MustCompile(`[!@#~$%^&*()+|_.,<>?/{}\-]`)
-
-    switch {
-    case !upper.MatchString(password):
-        return ErrMissingUpper
-    case !lower.MatchString(password):
-        return ErrMissingLower
-    case !number.MatchString(password):
-        return ErrMissingNumber
-    case !special.MatchString(password):
-        return ErrMissingSpecial
-    }
-
-    return nil
+//go:embed breached-passwords.txt
+var bundledBreachedPasswords string
+
+// breachedPasswords is the bundled list merged with the optional PASSWORD_BREACHED_LIST_FILE.
+var breachedPasswords = sync.OnceValue(func() map[string]struct{} {
+	list := make(map[string]struct{})
+	addPasswords(list, bufio.NewScanner(strings.NewReader(bundledBreachedPasswords)))
+
+	if path := env.GetStringEnv("PASSWORD_BREACHED_LIST_FILE", ""); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Printf("⚠️ Could not read breached password list %s: %v", path, err)
+			return list
+		}
+		defer file.Close()
+
+		addPasswords(list, bufio.NewScanner(file))
+	}
+
+	return list
+})
+
+func addPasswords(list map[string]struct{}, scanner *bufio.Scanner) {
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list[strings.ToLower(line)] = struct{}{}
+	}
+}
+
+// PasswordPolicy holds the rules a new password must satisfy.
+type PasswordPolicy struct {
+	MinLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireNumber  bool
+	RequireSpecial bool
+	RejectBreached bool
+
+	// MaxAge is how long a password can be used before it must be changed, zero disables it
+	MaxAge time.Duration
+	// HistorySize is how many previous passwords cannot be reused, zero disables it
+	HistorySize int
+}
+
+// PasswordPolicyFromEnv reads the password policy. The defaults match the rules
+// the service has always enforced.
+func PasswordPolicyFromEnv() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      env.GetIntEnv("PASSWORD_MIN_LENGTH", 8),
+		RequireUpper:   env.GetBoolEnv("PASSWORD_REQUIRE_UPPER", true),
+		RequireLower:   env.GetBoolEnv("PASSWORD_REQUIRE_LOWER", true),
+		RequireNumber:  env.GetBoolEnv("PASSWORD_REQUIRE_NUMBER", true),
+		RequireSpecial: env.GetBoolEnv("PASSWORD_REQUIRE_SPECIAL", true),
+		RejectBreached: env.GetBoolEnv("PASSWORD_REJECT_BREACHED", true),
+		MaxAge:         env.GetDurationEnv("PASSWORD_MAX_AGE", 0),
+		HistorySize:    env.GetIntEnv("PASSWORD_HISTORY_SIZE", 5),
+	}
+}
+
+// Validate returns every rule password breaks, or nil when it satisfies the policy.
+func (p PasswordPolicy) Validate(password string) []string {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+	if p.RequireUpper && !upperPattern.MatchString(password) {
+		violations = append(violations, "password must contain at least one uppercase letter")
+	}
+	if p.RequireLower && !lowerPattern.MatchString(password) {
+		violations = append(violations, "password must contain at least one lowercase letter")
+	}
+	if p.RequireNumber && !numberPattern.MatchString(password) {
+		violations = append(violations, "password must contain at least one number")
+	}
+	if p.RequireSpecial && !specialPattern.MatchString(password) {
+		violations = append(violations, "password must contain at least one special character")
+	}
+	if p.RejectBreached {
+		if _, ok := breachedPasswords()[strings.ToLower(password)]; ok {
+			violations = append(violations, "password is too common or has appeared in a data breach")
+		}
+	}
+
+	return violations
+}
+
+// Expired reports whether a password last changed at changedAt is older than MaxAge.
+func (p PasswordPolicy) Expired(changedAt time.Time) bool {
+	return p.MaxAge > 0 && time.Since(changedAt) > p.MaxAge
+}
+
+// ValidatePassword checks a password against the configured password policy
+// and returns every violation at once.
+func ValidatePassword(password string) []string {
+	return PasswordPolicyFromEnv().Validate(password)
 }
@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/entities"
@@ -23,8 +24,9 @@ func HandlePermissions(permission constants.Permission, next http.HandlerFunc) h
 	})
 }
 
+// CheckPermission reports whether the granted permissions in permMap cover permission,
+// either by name, by an implication or by a wildcard.
 func CheckPermission(permission constants.Permission, permMap map[string]struct{}) bool {
-
 	if permMap == nil {
 		return false
 	}
@@ -33,14 +35,80 @@ func CheckPermission(permission constants.Permission, permMap map[string]struct{
 		return true
 	}
 
-	_, full := permMap[string(constants.FullAccess)]
+	for granted := range permMap {
+		if strings.Contains(granted, "*") && MatchPermission(granted, permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasPermission reports whether perms grant permission.
+func HasPermission(perms []string, permission constants.Permission) bool {
+	return CheckPermission(permission, genPermMap(perms))
+}
+
+// MatchPermission reports whether granted covers permission. A * segment matches any
+// one segment, and as the last segment it matches every segment that follows.
+func MatchPermission(granted string, permission constants.Permission) bool {
+	if granted == string(permission) {
+		return true
+	}
+
+	grantedParts := strings.Split(granted, ":")
+	parts := strings.Split(string(permission), ":")
+
+	for i, part := range grantedParts {
+		if part == "*" && i == len(grantedParts)-1 {
+			return len(parts) > i
+		}
+		if i >= len(parts) || (part != "*" && part != parts[i]) {
+			return false
+		}
+	}
+
+	return len(grantedParts) == len(parts)
+}
+
+// NormalizePermission returns the current name of a permission granted under its legacy name.
+func NormalizePermission(name string) string {
+	if current, ok := constants.LegacyPermissions[name]; ok {
+		return string(current)
+	}
+
+	return name
+}
+
+// ExpandPermissions returns perms under their current names together with every
+// permission they imply.
+func ExpandPermissions(perms []string) []string {
+	seen := make(map[string]struct{}, len(perms))
+	expanded := make([]string, 0, len(perms))
+
+	var add func(name string)
+	add = func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		expanded = append(expanded, name)
+
+		for _, implied := range constants.PermissionImplications[constants.Permission(name)] {
+			add(string(implied))
+		}
+	}
 
-	return full
+	for _, perm := range perms {
+		add(NormalizePermission(perm))
+	}
+
+	return expanded
 }
 
 func genPermMap(perms []string) map[string]struct{} {
 	permMap := make(map[string]struct{})
-	for _, perm := range perms {
+	for _, perm := range ExpandPermissions(perms) {
 		permMap[perm] = struct{}{}
 	}
 
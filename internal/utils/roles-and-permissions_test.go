@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
+)
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		granted    string
+		permission constants.Permission
+		want       bool
+	}{
+		{name: "exact", granted: "orders:read", permission: constants.ViewOrders, want: true},
+		{name: "other action", granted: "orders:read", permission: constants.CreateOrder, want: false},
+		{name: "other resource", granted: "orders:read", permission: constants.ViewPayments, want: false},
+		{name: "full access", granted: "*", permission: constants.UpdateOrderStatus, want: true},
+		{name: "trailing wildcard covers one segment", granted: "orders:*", permission: constants.CancelOrder, want: true},
+		{name: "trailing wildcard covers nested segments", granted: "orders:*", permission: constants.UpdateOrderStatus, want: true},
+		{name: "trailing wildcard needs a segment", granted: "orders:*", permission: "orders", want: false},
+		{name: "trailing wildcard stays in its resource", granted: "orders:*", permission: constants.ViewPayments, want: false},
+		{name: "inner wildcard matches one segment", granted: "*:read", permission: constants.ViewProducts, want: true},
+		{name: "inner wildcard does not match deeper", granted: "*:read", permission: constants.ViewOwnOrders, want: false},
+		{name: "inner wildcard needs the rest to match", granted: "*:read", permission: constants.CreateProduct, want: false},
+		{name: "nested wildcard", granted: "orders:*:update", permission: constants.UpdateOrderStatus, want: true},
+		{name: "longer grant", granted: "orders:read:own", permission: constants.ViewOrders, want: false},
+		{name: "shorter grant", granted: "orders:read", permission: constants.ViewOwnOrders, want: false},
+		{name: "prefix of a segment", granted: "orders:re", permission: constants.ViewOrders, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchPermission(tt.granted, tt.permission); got != tt.want {
+				t.Errorf("MatchPermission(%q, %q) = %v, want %v", tt.granted, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandPermissions(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms []string
+		want  []string
+	}{
+		{
+			name:  "no implications",
+			perms: []string{"orders:create"},
+			want:  []string{"orders:create"},
+		},
+		{
+			name:  "direct implication",
+			perms: []string{"products:update"},
+			want:  []string{"products:update", "products:read"},
+		},
+		{
+			name:  "transitive implications",
+			perms: []string{"orders:refund"},
+			want:  []string{"orders:refund", "orders:read", "orders:read:own", "payments:read", "payments:read:own"},
+		},
+		{
+			name:  "shared implications are listed once",
+			perms: []string{"orders:cancel", "orders:status:update"},
+			want:  []string{"orders:cancel", "orders:read", "orders:read:own", "orders:cancel:own", "orders:status:update"},
+		},
+		{
+			name:  "legacy names",
+			perms: []string{"view_orders", "full_access"},
+			want:  []string{"orders:read", "orders:read:own", "*"},
+		},
+		{
+			name:  "unknown permissions are kept",
+			perms: []string{"reports:custom"},
+			want:  []string{"reports:custom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExpandPermissions(tt.perms)
+
+			slices.Sort(got)
+			want := slices.Clone(tt.want)
+			slices.Sort(want)
+
+			if !slices.Equal(got, want) {
+				t.Errorf("ExpandPermissions(%v) = %v, want %v", tt.perms, got, want)
+			}
+		})
+	}
+}
+
+func TestPermissionImplications(t *testing.T) {
+	known := make(map[constants.Permission]bool, len(constants.Catalog))
+	for _, info := range constants.Catalog {
+		known[info.Name] = true
+	}
+
+	for permission, implied := range constants.PermissionImplications {
+		if !known[permission] {
+			t.Errorf("%s is not in the catalog", permission)
+		}
+
+		for _, p := range implied {
+			if !known[p] {
+				t.Errorf("%s implies %s, which is not in the catalog", permission, p)
+			}
+			if MatchPermission(string(p), permission) {
+				t.Errorf("%s implies %s, which already covers it", permission, p)
+			}
+		}
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		perms      []string
+		permission constants.Permission
+		want       bool
+	}{
+		{name: "granted", perms: []string{"orders:read"}, permission: constants.ViewOrders, want: true},
+		{name: "not granted", perms: []string{"orders:read"}, permission: constants.RefundOrder, want: false},
+		{name: "implied", perms: []string{"orders:refund"}, permission: constants.ViewOwnPayments, want: true},
+		{name: "implication only goes one way", perms: []string{"orders:read:own"}, permission: constants.ViewOrders, want: false},
+		{name: "wildcard", perms: []string{"payments:*"}, permission: constants.RefundPayment, want: true},
+		{name: "legacy name", perms: []string{"update_order_status"}, permission: constants.ViewOwnOrders, want: true},
+		{name: "full access", perms: []string{"*"}, permission: constants.ManageRoles, want: true},
+		{name: "no permissions", perms: nil, permission: constants.ViewProducts, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.perms, tt.permission); got != tt.want {
+				t.Errorf("HasPermission(%v, %q) = %v, want %v", tt.perms, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
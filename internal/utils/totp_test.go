@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors, "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 appendix D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range want {
+		if got := hotp([]byte("12345678901234567890"), int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      int64
+		wantStep int64
+		wantOK   bool
+	}{
+		// RFC 6238 appendix B, truncated to 6 digits
+		{name: "vector 59", secret: rfc6238Secret, code: "287082", now: 59, wantStep: 1, wantOK: true},
+		{name: "vector 1111111109", secret: rfc6238Secret, code: "081804", now: 1111111109, wantStep: 37037036, wantOK: true},
+		{name: "vector 1234567890", secret: rfc6238Secret, code: "005924", now: 1234567890, wantStep: 41152263, wantOK: true},
+		{name: "vector 2000000000", secret: rfc6238Secret, code: "279037", now: 2000000000, wantStep: 66666666, wantOK: true},
+
+		{name: "previous step within skew", secret: rfc6238Secret, code: "287082", now: 89, wantStep: 1, wantOK: true},
+		{name: "next step within skew", secret: rfc6238Secret, code: "287082", now: 29, wantStep: 1, wantOK: true},
+		{name: "two steps late", secret: rfc6238Secret, code: "287082", now: 90, wantOK: false},
+		{name: "wrong code", secret: rfc6238Secret, code: "000000", now: 59, wantOK: false},
+		{name: "too short", secret: rfc6238Secret, code: "28708", now: 59, wantOK: false},
+		{name: "too long", secret: rfc6238Secret, code: "2870820", now: 59, wantOK: false},
+		{name: "lowercase secret with spaces", secret: " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", code: "287082", now: 59, wantStep: 1, wantOK: true},
+		{name: "invalid secret", secret: "not base32!", code: "287082", now: 59, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.now, 0))
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTP() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != tt.wantStep {
+				t.Errorf("ValidateTOTP() step = %d, want %d", step, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret has %d bytes, want 20", len(key))
+	}
+
+	now := time.Now()
+	code := hotp(key, now.Unix()/totpPeriod)
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Error("ValidateTOTP() rejected the current code of a generated secret")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI("Shop Backend", "jane@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("URI starts with %s://%s, want otpauth://totp", uri.Scheme, uri.Host)
+	}
+	if uri.Path != "/Shop Backend:jane@example.com" {
+		t.Errorf("URI label = %q, want %q", uri.Path, "/Shop Backend:jane@example.com")
+	}
+
+	query := uri.Query()
+	for key, want := range map[string]string{"secret": rfc6238Secret, "issuer": "Shop Backend", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("URI %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want 10", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q does not match xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 
+	"github.com/Aboagye-Dacosta/shopBackend/internal/catalog"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
 	database "github.com/Aboagye-Dacosta/shopBackend/internal/database/db"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
@@ -11,22 +15,51 @@ import (
 )
 
 func main() {
+	checkOnly := flag.Bool("check-permissions", false, "report permission catalog drift and exit non-zero when there is any")
+	flag.Parse()
+
 	env.LoadEnv()
 	db := database.ConnectDB()
 
+	if *checkOnly {
+		if err := reportPermissionDrift(db); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Println("✅ Permissions match the catalog")
+		return
+	}
+
 	appModels := []interface{}{
 		&models.Permission{},
 		&models.Role{},
 		&models.User{},
+		&models.UserRole{},
 		&models.Customer{},
 		&models.Product{},
 		&models.Order{},
 		&models.Payment{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.PasswordResetToken{},
+		&models.UserMFA{},
+		&models.RecoveryCode{},
+		&models.OAuthState{},
+		&models.UserIdentity{},
+		&models.APIKey{},
+		&models.Session{},
+		&models.PasswordHistory{},
+		&models.ImpersonationLog{},
+		&models.SuspensionHistory{},
+		&models.DataExport{},
+		&models.ErasureRequest{},
 	}
 
 	if err := migrateAndSeed(db, appModels...); err != nil {
 		log.Fatalf("❌ Migration failed: %v", err)
 	}
+	if err := dropLegacyEmailIndex(db); err != nil {
+		log.Fatalf("❌ Migration failed: %v", err)
+	}
 	log.Println("✅ Migration and seeding completed successfully!")
 }
 
@@ -35,6 +68,9 @@ func migrateAndSeed(db *gorm.DB, models ...interface{}) error {
 	if err := db.AutoMigrate(models...); err != nil {
 		return err
 	}
+	if err := migrateLegacyPermissions(db); err != nil {
+		return err
+	}
 
 	log.Println("🌱 Seeding initial data...")
 	if err := seed.SeedPermissions(db); err != nil {
@@ -46,9 +82,117 @@ func migrateAndSeed(db *gorm.DB, models ...interface{}) error {
 	if err := seed.SeedUserRole(db); err != nil {
 		return err
 	}
+	if err := seed.ScopeUserRole(db); err != nil {
+		return err
+	}
 	if err := seed.SeedSuperAdmin(db); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// dropLegacyEmailIndex removes the unique index that also covered deleted users.
+// Emails are now unique among active users only, see idx_users_email_active.
+func dropLegacyEmailIndex(db *gorm.DB) error {
+	if !db.Migrator().HasIndex(&models.User{}, "idx_users_email") {
+		return nil
+	}
+
+	log.Println("🧹 Dropping legacy users email index...")
+	return db.Migrator().DropIndex(&models.User{}, "idx_users_email")
+}
+
+// migrateLegacyPermissions renames permissions stored before they were namespaced.
+// When the new name already exists the role grants move over to it, and API keys
+// get their permission lists rewritten.
+func migrateLegacyPermissions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for legacy, current := range constants.LegacyPermissions {
+			var old models.Permission
+			if err := tx.Where("name = ?", legacy).Limit(1).Find(&old).Error; err != nil {
+				return err
+			}
+			if old.ID == "" {
+				continue
+			}
+
+			var existing models.Permission
+			if err := tx.Where("name = ?", string(current)).Limit(1).Find(&existing).Error; err != nil {
+				return err
+			}
+
+			if existing.ID == "" {
+				log.Printf("🔁 Renaming permission %s to %s", legacy, current)
+				if err := tx.Model(&old).Update("name", string(current)).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			log.Printf("🔁 Merging permission %s into %s", legacy, current)
+			if err := tx.Exec(
+				`INSERT INTO role_permissions (role_id, permission_id)
+				SELECT role_id, ? FROM role_permissions WHERE permission_id = ?
+				ON CONFLICT DO NOTHING`,
+				existing.ID, old.ID,
+			).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM role_permissions WHERE permission_id = ?", old.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&old).Error; err != nil {
+				return err
+			}
+		}
+
+		var keys []models.APIKey
+		if err := tx.Find(&keys).Error; err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			changed := false
+			for i, perm := range key.Permissions {
+				if current, ok := constants.LegacyPermissions[perm]; ok {
+					key.Permissions[i] = string(current)
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+
+			if err := tx.Model(&key).Select("Permissions").Updates(models.APIKey{Permissions: key.Permissions}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// reportPermissionDrift lists where the permissions table disagrees with the catalog,
+// for CI. Running the migration syncs the missing and outdated permissions.
+func reportPermissionDrift(db *gorm.DB) error {
+	drift, err := catalog.Diff(db)
+	if err != nil {
+		return err
+	}
+	if drift.Empty() {
+		return nil
+	}
+
+	for _, name := range drift.Missing {
+		log.Printf("➕ %s is in the catalog but not in the database", name)
+	}
+	for _, name := range drift.Unknown {
+		log.Printf("❓ %s is in the database but not in the catalog", name)
+	}
+	for _, name := range drift.Outdated {
+		log.Printf("✏️ %s differs from its catalog entry", name)
+	}
+
+	return fmt.Errorf("permission catalog drift: %d missing, %d unknown, %d outdated", len(drift.Missing), len(drift.Unknown), len(drift.Outdated))
+}
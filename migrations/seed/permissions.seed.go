@@ -1,55 +1,21 @@
 package seed
 
 import (
-	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"log"
+
+	"github.com/Aboagye-Dacosta/shopBackend/internal/catalog"
 	"gorm.io/gorm"
 )
 
-var DefaultPermissions = []string{
-	//admin
-	"full_access",
-	// Products
-	"view_products",
-	"create_product",
-	"update_product",
-	"delete_product",
-	"update_inventory",
-
-	// Orders
-	"view_orders",
-	"create_order",
-	"update_order_status",
-	"cancel_order",
-	"refund_order",
-
-	// Payments
-	"view_payments",
-	"create_payment",
-	"refund_payment",
-
-	// Users
-	"view_users",
-	"create_user",
-	"update_user",
-	"delete_user",
-	"ban_user",
-
-	// Reports
-	"view_reports",
-	"export_data",
-
-	// System
-	"manage_roles",
-	"manage_permissions",
-	"manage_settings",
-}
-
+// SeedPermissions writes the permission catalog of constants.Catalog to the database.
 func SeedPermissions(db *gorm.DB) error {
-	for _, name := range DefaultPermissions {
-		var p models.Permission
-		if err := db.Where(models.Permission{Name: name}).FirstOrCreate(&p, models.Permission{Name: name}).Error; err != nil {
-			return err
-		}
+	drift, err := catalog.Sync(db)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range drift.Unknown {
+		log.Printf("⚠️ Permission %s is not in the catalog", name)
 	}
 	return nil
 }
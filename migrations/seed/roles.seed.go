@@ -2,18 +2,45 @@ package seed
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/Aboagye-Dacosta/shopBackend/internal/constants"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
+	"github.com/Aboagye-Dacosta/shopBackend/internal/utils"
 	"gorm.io/gorm"
 )
 
+// RoleGrants are the permissions of the seeded roles. A grant can be a wildcard such
+// as "orders:*", it is stored as a permission of its own and matched when checked.
+var RoleGrants = map[string][]string{
+	"admin": {"*"},
+	"user": {
+		"products:read",
+		"orders:create",
+		"orders:read:own",
+		"orders:cancel:own",
+		"payments:read:own",
+		"payments:create",
+	},
+}
+
+// UserRoleScopedGrants replace grants the user role had on every record with their
+// :own counterpart, see ScopeUserRole.
+var UserRoleScopedGrants = map[string]string{
+	"orders:read":   "orders:read:own",
+	"orders:cancel": "orders:cancel:own",
+	"payments:read": "payments:read:own",
+}
+
 func SeedAdminRole(db *gorm.DB) error {
 	admin := models.Role{Name: "admin"}
 	db.FirstOrCreate(&admin, models.Role{Name: "admin"})
 
-	var perms models.Permission
+	perms, err := grantPermissions(db, RoleGrants["admin"])
+	if err != nil {
+		return err
+	}
 
-	db.Where("name=?", "full_access").Find(&perms)
 	if err := db.Model(&admin).Association("Permissions").Replace(perms); err != nil {
 		return err
 	}
@@ -28,14 +55,6 @@ func SeedUserRole(db *gorm.DB) error {
 		return err
 	}
 
-	permissions := []string{
-		"view_products",
-		"create_order",
-		"view_orders",
-		"create_payment",
-		"cancel_order",
-	}
-
 	var existingPerms []models.Permission
 	if err := db.Model(&userRole).Association("Permissions").Find(&existingPerms); err != nil {
 		return err
@@ -46,14 +65,14 @@ func SeedUserRole(db *gorm.DB) error {
 		existingMap[p.Name] = true
 	}
 
-	for _, name := range permissions {
-		var perm models.Permission
-		if err := db.FirstOrCreate(&perm, models.Permission{Name: name}).Error; err != nil {
-			return err
-		}
+	perms, err := grantPermissions(db, RoleGrants["user"])
+	if err != nil {
+		return err
+	}
 
-		if !existingMap[name] {
-			if err := db.Model(&userRole).Association("Permissions").Append(&perm); err != nil {
+	for i := range perms {
+		if !existingMap[perms[i].Name] {
+			if err := db.Model(&userRole).Association("Permissions").Append(&perms[i]); err != nil {
 				return err
 			}
 		}
@@ -62,3 +81,60 @@ func SeedUserRole(db *gorm.DB) error {
 	fmt.Println("✅ User role seeded successfully with permissions")
 	return nil
 }
+
+// ScopeUserRole removes the grants on every record the user role received before
+// ownership rules existed. SeedUserRole has to run first to grant the :own ones.
+func ScopeUserRole(db *gorm.DB) error {
+	var userRole models.Role
+	if err := db.Where("name = ?", "user").First(&userRole).Error; err != nil {
+		return err
+	}
+
+	var granted []models.Permission
+	if err := db.Model(&userRole).Association("Permissions").Find(&granted); err != nil {
+		return err
+	}
+
+	for i := range granted {
+		if _, ok := UserRoleScopedGrants[granted[i].Name]; !ok {
+			continue
+		}
+
+		if err := db.Model(&userRole).Association("Permissions").Delete(&granted[i]); err != nil {
+			return err
+		}
+		fmt.Printf("🔒 Scoped %s of the user role to own records\n", granted[i].Name)
+	}
+
+	return nil
+}
+
+// grantPermissions returns the permission rows of grants, creating the rows of wildcard
+// grants. A wildcard has to cover at least one catalog permission to catch typos.
+func grantPermissions(db *gorm.DB, grants []string) ([]models.Permission, error) {
+	perms := make([]models.Permission, 0, len(grants))
+
+	for _, grant := range grants {
+		if strings.Contains(grant, "*") && !coversDefault(grant) {
+			return nil, fmt.Errorf("role grant %q does not cover any permission", grant)
+		}
+
+		var perm models.Permission
+		if err := db.FirstOrCreate(&perm, models.Permission{Name: grant}).Error; err != nil {
+			return nil, err
+		}
+		perms = append(perms, perm)
+	}
+
+	return perms, nil
+}
+
+func coversDefault(grant string) bool {
+	for _, info := range constants.Catalog {
+		if string(info.Name) != grant && utils.MatchPermission(grant, info.Name) {
+			return true
+		}
+	}
+
+	return false
+}
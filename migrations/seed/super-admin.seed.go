@@ -3,6 +3,7 @@ package seed
 import (
 	"errors"
 	"log"
+	"time"
 
 	"github.com/Aboagye-Dacosta/shopBackend/internal/database/models"
 	"github.com/Aboagye-Dacosta/shopBackend/internal/env"
@@ -26,11 +27,13 @@ func SeedSuperAdmin(db *gorm.DB) error {
 			return err
 		}
 
+		now := time.Now()
 		user = models.User{
-			FirstName: "Super",
-			LastName:  "Admin",
-			Email:     email,
-			Password:  hashed,
+			FirstName:   "Super",
+			LastName:    "Admin",
+			Email:       email,
+			Password:    hashed,
+			ActivatedAt: &now,
 		}
 
 		if err := db.Create(&user).Error; err != nil {